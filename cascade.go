@@ -0,0 +1,45 @@
+package godotenv
+
+// LoadCascade loads the env files for the given environment, e.g. development, following the
+// dotenv convention. The files are loaded in the following order, where files earlier in the
+// list take precedence over the files later in the list:
+//
+//	.env.{env}.local
+//	.env.local (skipped when env is "test", so that tests are reproducible)
+//	.env.{env}
+//	.env
+//
+// Only .env is required, the other files are skipped if they don't exist. The files for the
+// environment are skipped altogether if env is empty. It returns the files that were loaded, which
+// are looked up in opts.FS, or in the parent directories if opts.SearchAncestors is set.
+//
+// Variables that already exist in the environment are not overridden, unless opts.Overload is set.
+// Files earlier in the list take precedence unless a different opts.MergeStrategy is set.
+func LoadCascade(env string, opts LoadOptions) (loaded []string, err error) {
+	if opts.MergeStrategy == nil {
+		opts.MergeStrategy = FirstWins
+	}
+
+	filenames, optional := CascadeFiles(env)
+	opts.Optional = append(optional, opts.Optional...)
+
+	_, loaded, err = loadFiles(filenames, opts)
+	return loaded, err
+}
+
+// CascadeFiles returns the files LoadCascade loads for the given environment, in order of precedence,
+// along with the files that are optional, which are all files but .env.
+func CascadeFiles(env string) (filenames, optional []string) {
+	if env != "" {
+		optional = append(optional, ".env."+env+".local")
+	}
+	if env != "test" {
+		optional = append(optional, ".env.local")
+	}
+	if env != "" {
+		optional = append(optional, ".env."+env)
+	}
+
+	filenames = append(filenames, optional...)
+	return append(filenames, ".env"), optional
+}
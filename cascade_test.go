@@ -0,0 +1,216 @@
+package godotenv_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+// chdirTemp changes the working directory to a temporary directory holding the given files,
+// and changes it back once the test is done.
+func chdirTemp(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chdir(wd)
+	})
+
+	return dir
+}
+
+func TestLoadCascade(t *testing.T) {
+	files := map[string]string{
+		".env":             "A=env\nB=env\nC=env\nD=env\n",
+		".env.development": "A=development\nB=development\nC=development\n",
+		".env.local":       "A=local\nB=local\n",
+		".env.test":        "A=test\nB=test\nC=test\n",
+	}
+
+	tests := []struct {
+		name     string
+		env      string
+		loaded   []string
+		expected map[string]string
+	}{
+		{
+			name:     "development",
+			env:      "development",
+			loaded:   []string{".env.local", ".env.development", ".env"},
+			expected: map[string]string{"A": "local", "B": "local", "C": "development", "D": "env"},
+		},
+		{
+			name:     "test skips local",
+			env:      "test",
+			loaded:   []string{".env.test", ".env"},
+			expected: map[string]string{"A": "test", "B": "test", "C": "test", "D": "env"},
+		},
+		{
+			name:     "no environment",
+			loaded:   []string{".env.local", ".env"},
+			expected: map[string]string{"A": "local", "B": "local", "C": "env", "D": "env"},
+		},
+	}
+
+	chdirTemp(t, files)
+
+	for _, tt := range tests {
+		os.Clearenv()
+
+		loaded, err := godotenv.LoadCascade(tt.env, godotenv.LoadOptions{})
+		if err != nil {
+			t.Fatalf("%s: error loading: %s", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(tt.loaded, loaded) {
+			t.Errorf("%s: expected %v to be loaded, got %v", tt.name, tt.loaded, loaded)
+		}
+
+		for k, v := range tt.expected {
+			if envValue := os.Getenv(k); envValue != v {
+				t.Errorf("%s: mismatch for key '%v': expected '%v' got '%v'", tt.name, k, v, envValue)
+			}
+		}
+	}
+}
+
+func TestLoadCascadeRequiresDotEnv(t *testing.T) {
+	chdirTemp(t, map[string]string{".env.development": "A=development\n"})
+	os.Clearenv()
+
+	_, err := godotenv.LoadCascade("development", godotenv.LoadOptions{})
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
+
+	if _, exists := os.LookupEnv("A"); exists {
+		t.Errorf("Expected nothing to be loaded on error")
+	}
+}
+
+func TestLoadCascadeFromAncestors(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		"go.mod":           "module example.com/mod\n",
+		".env":             "A=env\nB=env\n",
+		".env.development": "A=development\n",
+		"pkg/.env.local":   "B=local\n",
+		"pkg/sub/doc.go":   "package sub\n",
+	})
+
+	if err := os.Chdir(filepath.Join(dir, "pkg", "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Clearenv()
+	loaded, err := godotenv.LoadCascade("development", godotenv.LoadOptions{SearchAncestors: true})
+	if err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+
+	// the working directory is reported with symlinks resolved, e.g. on macOS
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectedLoaded := []string{
+		filepath.Join(dir, "pkg", ".env.local"),
+		filepath.Join(dir, ".env.development"),
+		filepath.Join(dir, ".env"),
+	}
+	if !reflect.DeepEqual(expectedLoaded, loaded) {
+		t.Errorf("Expected %v to be loaded, got %v", expectedLoaded, loaded)
+	}
+
+	expected := map[string]string{"A": "development", "B": "local"}
+	for k, v := range expected {
+		if envValue := os.Getenv(k); envValue != v {
+			t.Errorf("Mismatch for key '%v': expected '%v' got '%v'", k, v, envValue)
+		}
+	}
+}
+
+func TestLoadCascadeFS(t *testing.T) {
+	chdirTemp(t, map[string]string{".env.local": "A=working directory\n"})
+
+	fsys := fstest.MapFS{
+		".env":             {Data: []byte("A=env\nB=env\n")},
+		".env.development": {Data: []byte("B=development\n")},
+	}
+
+	os.Clearenv()
+	loaded, err := godotenv.LoadCascade("development", godotenv.LoadOptions{FS: fsys})
+	if err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+
+	if expected := []string{".env.development", ".env"}; !reflect.DeepEqual(expected, loaded) {
+		t.Errorf("Expected %v to be loaded, got %v", expected, loaded)
+	}
+
+	expected := map[string]string{"A": "env", "B": "development"}
+	for k, v := range expected {
+		if envValue := os.Getenv(k); envValue != v {
+			t.Errorf("Mismatch for key '%v': expected '%v' got '%v'", k, v, envValue)
+		}
+	}
+}
+
+func TestLoadFromAncestors(t *testing.T) {
+	dir := chdirTemp(t, map[string]string{
+		".env":                 "A=outside\n",
+		"mod/go.mod":           "module example.com/mod\n",
+		"mod/.env":             "A=mod\nB=mod\n",
+		"mod/pkg/.env.local":   "B=pkg\n",
+		"mod/pkg/sub/doc.go":   "package sub\n",
+		"other/go.mod":         "module example.com/other\n",
+		"other/pkg/sub/doc.go": "package sub\n",
+	})
+
+	if err := os.Chdir(filepath.Join(dir, "mod", "pkg", "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Clearenv()
+	if err := godotenv.LoadFromAncestors(".env.local", ".env"); err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+
+	expected := map[string]string{"A": "mod", "B": "pkg"}
+	for k, v := range expected {
+		if envValue := os.Getenv(k); envValue != v {
+			t.Errorf("Mismatch for key '%v': expected '%v' got '%v'", k, v, envValue)
+		}
+	}
+
+	if err := os.Chdir(filepath.Join(dir, "other", "pkg", "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := godotenv.LoadFromAncestors(); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("Expected the search to stop at go.mod, got %v", err)
+	}
+}
@@ -11,17 +11,25 @@ import (
 )
 
 func main() {
-	var showVersion, overload bool
-	envFilenames := stringsFlag{".env"}
+	var showVersion, overload, strict, commands, ancestors bool
+	var environment, explainKey string
+	// left empty by default, as godotenv defaults to .env when no files are given
+	var envFilenames stringsFlag
 
 	flags := flag.NewFlagSet(projectName, flag.ContinueOnError)
 	flags.BoolVar(&showVersion, "v", false, "Show version information.")
-	flags.Var(&envFilenames, "f", "Comma separated paths to .env `files`. Repeat for multiple files.")
+	flags.Var(&envFilenames, "f", "Comma separated paths to .env `files`. Repeat for multiple files. Prefix with ? to skip the file if it doesn't exist. (default .env)")
+	flags.StringVar(&environment, "e", "", "Load the .env files for the `environment`, e.g. development, following the dotenv convention.")
+	flags.BoolVar(&ancestors, "a", false, "Search parent directories for the .env files, up to the nearest go.mod or .git.")
 	flags.BoolVar(&overload, "o", false, "Override existing .env variables.")
+	flags.BoolVar(&strict, "strict", false, "Error on references to unset variables.")
+	flags.BoolVar(&commands, "c", false, "Enable command substitution, e.g. $(git rev-parse HEAD).")
+	flags.StringVar(&explainKey, "explain", "", "Show where the value of `KEY` comes from instead of running a command.")
 
 	flags.Usage = func() {
 		_, _ = fmt.Fprintf(flags.Output(), `Usage:
   %[1]s [ options ] command [ arg ... ]
+  %[1]s [ options ] -explain KEY
 
 Utility to run a process with an env setup from a .env file.
 
@@ -33,6 +41,10 @@ Options:
 		_, _ = fmt.Fprintln(flags.Output(), `Example:
 	godotenv -f /path/to/something/.env -f /another/path/.env fortune
 	godotenv -o -f /path/to/something/.env -f /another/path/.env fortune
+	godotenv -f .env -f ?.env.local fortune
+	godotenv -strict -f /path/to/something/.env fortune
+	godotenv -e development fortune
+	godotenv -f /path/to/something/.env -f /another/path/.env -explain DATABASE_URL
 	`)
 		_, _ = fmt.Fprintf(flags.Output(), `For more information, see %s`, projectURL)
 		_, _ = fmt.Fprintln(flags.Output())
@@ -52,25 +64,56 @@ Options:
 	// if no args or help requested
 	// print usage and return
 	args := flags.Args()
-	if len(args) == 0 {
+	if len(args) == 0 && explainKey == "" {
 		flags.Usage()
 		os.Exit(1)
 	}
+	if len(args) != 0 && explainKey != "" {
+		log.Fatal("-explain cannot be used with a command")
+	}
 
-	loader := godotenv.Load
-	if overload {
-		loader = godotenv.Overload
+	opts := godotenv.LoadOptions{
+		ParseOptions: godotenv.ParseOptions{
+			Strict: strict,
+			Arg0:   projectName,
+			PID:    os.Getpid(),
+		},
+		Overload:        overload,
+		SearchAncestors: ancestors,
 	}
 
-	err = loader(envFilenames...)
-	if err != nil {
-		log.Fatal(err)
+	if commands {
+		opts.CommandRunner = godotenv.ExecCommandRunner{}
+	}
+
+	if environment != "" {
+		if len(envFilenames) != 0 {
+			log.Fatal("-e and -f cannot be used together")
+		}
+
+		envFilenames, opts.Optional = godotenv.CascadeFiles(environment)
+		opts.MergeStrategy = godotenv.FirstWins
+	} else {
+		envFilenames, opts.Optional = envFilenames.optional()
+	}
+
+	if explainKey != "" {
+		if err = explain(os.Stdout, explainKey, opts, envFilenames...); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
 
 	// take rest of args and "exec" them
 	cmd := args[0]
 	cmdArgs := args[1:]
+	opts.Args, opts.Arg0 = cmdArgs, cmd
+
+	err = godotenv.LoadWithOptions(opts, envFilenames...)
+	if err != nil {
+		log.Fatal(err)
+		return
+	}
 
 	err = execv(cmd, cmdArgs)
 	if err != nil {
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+// explain writes where the value of key comes from when loading the env files with opts.
+func explain(w io.Writer, key string, opts godotenv.LoadOptions, filenames ...string) error {
+	sources, err := godotenv.ReadWithSources(opts, filenames...)
+	if err != nil {
+		return err
+	}
+
+	source, ok := sources[key]
+	if !ok {
+		return fmt.Errorf("%s is not defined in any of the env files", key)
+	}
+
+	_, _ = fmt.Fprintf(w, "%s=%s\n", key, source.Value)
+	if source.FromEnv {
+		_, _ = fmt.Fprintln(w, "  set in the environment, which takes precedence over the env files")
+	} else {
+		_, _ = fmt.Fprintf(w, "  defined in %s: %s\n", source.Definition, source.Raw)
+	}
+
+	for _, definition := range source.Overridden {
+		_, _ = fmt.Fprintf(w, "  overrides %s: %s\n", definition, definition.Raw)
+	}
+
+	return nil
+}
@@ -12,3 +12,16 @@ func (f *stringsFlag) Set(value string) error {
 func (f stringsFlag) String() string {
 	return strings.Join(f, ",")
 }
+
+// optional returns the paths with the ? prefix removed, along with the paths that had it.
+func (f stringsFlag) optional() (filenames stringsFlag, optional []string) {
+	for _, filename := range f {
+		if strings.HasPrefix(filename, "?") {
+			filename = filename[1:]
+			optional = append(optional, filename)
+		}
+		filenames = append(filenames, filename)
+	}
+
+	return filenames, optional
+}
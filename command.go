@@ -0,0 +1,95 @@
+package godotenv
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// DefaultCommandTimeout is the time after which commands run by ExecCommandRunner are killed if
+// no timeout is configured.
+const DefaultCommandTimeout = 10 * time.Second
+
+// CommandRunner runs the commands in command substitutions, such as $(git rev-parse HEAD).
+type CommandRunner interface {
+	// RunCommand runs the command and returns its output. env holds the variables that have been
+	// parsed so far, including those from earlier files when loading multiple files, which should
+	// be made available to the command.
+	RunCommand(command string, env map[string]string) (output []byte, err error)
+}
+
+// CommandRunnerFunc is an adapter to allow the use of ordinary functions as a CommandRunner.
+type CommandRunnerFunc func(command string, env map[string]string) ([]byte, error)
+
+// RunCommand calls f(command, env).
+func (f CommandRunnerFunc) RunCommand(command string, env map[string]string) ([]byte, error) {
+	return f(command, env)
+}
+
+// ExecCommandRunner is a CommandRunner that runs commands in a shell using os/exec.
+type ExecCommandRunner struct {
+	// Shell is the shell, and its arguments, that the command is passed to. It defaults
+	// to `sh -c`, or `cmd /C` on Windows.
+	Shell []string
+	// Dir is the working directory of the command. It defaults to the current directory.
+	Dir string
+	// Timeout is the time after which the command is killed. It defaults to DefaultCommandTimeout.
+	Timeout time.Duration
+}
+
+// RunCommand runs the command in a shell, with env added to the environment of the current process.
+func (r ExecCommandRunner) RunCommand(command string, env map[string]string) ([]byte, error) {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultCommandTimeout
+	}
+
+	shell := r.Shell
+	if len(shell) == 0 {
+		shell = []string{"sh", "-c"}
+		if runtime.GOOS == "windows" {
+			shell = []string{"cmd", "/C"}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	args := append(shell[1:len(shell):len(shell)], command)
+	cmd := exec.CommandContext(ctx, shell[0], args...)
+	cmd.Dir = r.Dir
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	// the command is waited on in the background, as Wait blocks until the output is closed,
+	// which may be held open by processes started by the command after it has been killed
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil && stderr.Len() > 0 {
+			return nil, fmt.Errorf("%w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+		}
+
+		return stdout.Bytes(), err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out after %s", timeout)
+	}
+}
@@ -0,0 +1,190 @@
+package godotenv_test
+
+import (
+	"errors"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestCommandSubstitution(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		commands []string
+	}{
+		{
+			name:     "unquoted",
+			input:    "GIT_SHA=$(git rev-parse HEAD)",
+			expected: map[string]string{"GIT_SHA": "output of git rev-parse HEAD"},
+			commands: []string{"git rev-parse HEAD"},
+		},
+		{
+			name:     "double quoted",
+			input:    `VERSION="v$(cat VERSION)-dev"`,
+			expected: map[string]string{"VERSION": "voutput of cat VERSION-dev"},
+			commands: []string{"cat VERSION"},
+		},
+		{
+			name:     "single quoted is not substituted",
+			input:    `VERSION='$(cat VERSION)'`,
+			expected: map[string]string{"VERSION": "$(cat VERSION)"},
+		},
+		{
+			name:     "nested parentheses and quotes",
+			input:    `FOO=$(echo "(a)" ')' $(date))`,
+			expected: map[string]string{"FOO": `output of echo "(a)" ')' $(date)`},
+			commands: []string{`echo "(a)" ')' $(date)`},
+		},
+		{
+			name:     "in default words",
+			input:    "FOO=${BAR:-$(hostname)}",
+			expected: map[string]string{"FOO": "output of hostname"},
+			commands: []string{"hostname"},
+		},
+		{
+			name:     "not run for unused words",
+			input:    "BAR=1\nFOO=${BAR:-$(hostname)}",
+			expected: map[string]string{"BAR": "1", "FOO": "1"},
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var commands []string
+			runner := godotenv.CommandRunnerFunc(func(command string, env map[string]string) ([]byte, error) {
+				commands = append(commands, command)
+				return []byte("output of " + command + "\n\n"), nil
+			})
+
+			envMap, err := godotenv.ParseWithOptions(strings.NewReader(tt.input), godotenv.ParseOptions{CommandRunner: runner})
+			if err != nil {
+				t.Fatalf("Error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(tt.expected, envMap) {
+				t.Errorf("Mismatch env vars")
+				printDiff(t, tt.expected, envMap)
+			}
+			if !reflect.DeepEqual(tt.commands, commands) {
+				t.Errorf("Expected commands %q to run, got %q", tt.commands, commands)
+			}
+		})
+	}
+}
+
+func TestCommandSubstitutionDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	envMap, err := godotenv.Unmarshal("FOO=$(whoami)")
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if envMap["FOO"] != "(whoami)" {
+		t.Errorf("Expected command to not be run, got %q", envMap["FOO"])
+	}
+}
+
+func TestCommandSubstitutionError(t *testing.T) {
+	t.Parallel()
+
+	failure := errors.New("exit status 1")
+	runner := godotenv.CommandRunnerFunc(func(command string, env map[string]string) ([]byte, error) {
+		return nil, failure
+	})
+
+	_, err := godotenv.ParseWithOptions(strings.NewReader("A=1\nFOO=\"x$(false\n)\""), godotenv.ParseOptions{CommandRunner: runner})
+
+	var commandErr godotenv.CommandError
+	if !errors.As(err, &commandErr) {
+		t.Fatalf("Expected a CommandError, got %v", err)
+	}
+	if commandErr.Command != "false\n" || commandErr.Line != 2 || commandErr.Column != 7 {
+		t.Errorf("Expected %q to fail at 2:7, got %q at %d:%d", "false\n", commandErr.Command, commandErr.Line, commandErr.Column)
+	}
+	if !errors.Is(err, failure) {
+		t.Errorf("Expected error to wrap %v", failure)
+	}
+}
+
+func TestCommandSubstitutionSeesEarlierFiles(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		".env":       {Data: []byte("HOST=example.com\nPORT=1\n")},
+		".env.local": {Data: []byte("PORT=2\nURL=$(url)\n")},
+	}
+
+	tests := []struct {
+		name     string
+		strategy godotenv.MergeStrategy
+		expected map[string]string
+	}{
+		{name: "last wins", strategy: godotenv.LastWins, expected: map[string]string{"HOST": "example.com", "PORT": "2"}},
+		{name: "first wins", strategy: godotenv.FirstWins, expected: map[string]string{"HOST": "example.com", "PORT": "1"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var commandEnv map[string]string
+			runner := godotenv.CommandRunnerFunc(func(command string, env map[string]string) ([]byte, error) {
+				commandEnv = make(map[string]string, len(env))
+				for k, v := range env {
+					commandEnv[k] = v
+				}
+				return []byte(env["HOST"] + ":" + env["PORT"]), nil
+			})
+
+			_, err := godotenv.ReadWithOptions(godotenv.LoadOptions{
+				ParseOptions:  godotenv.ParseOptions{LookupEnv: noLookupEnv, CommandRunner: runner},
+				FS:            fsys,
+				MergeStrategy: tt.strategy,
+			}, ".env", ".env.local")
+			if err != nil {
+				t.Fatalf("Error reading files: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, commandEnv) {
+				t.Errorf("Expected the command to see %v, got %v", tt.expected, commandEnv)
+			}
+		})
+	}
+}
+
+func TestExecCommandRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("requires a POSIX shell")
+	}
+
+	t.Parallel()
+
+	// other tests clear the environment, so PATH can't be relied on
+	shell := []string{"/bin/sh", "-c"}
+
+	opts := godotenv.ParseOptions{CommandRunner: godotenv.ExecCommandRunner{Shell: shell}}
+	envMap, err := godotenv.ParseWithOptions(strings.NewReader("NAME=world\nGREETING=\"$(echo hello $NAME)\""), opts)
+	if err != nil {
+		t.Fatalf("Error: %s", err.Error())
+	}
+	if envMap["GREETING"] != "hello world" {
+		t.Errorf("Expected %q, got %q", "hello world", envMap["GREETING"])
+	}
+
+	opts.CommandRunner = godotenv.ExecCommandRunner{Shell: shell, Timeout: 10 * time.Millisecond}
+	_, err = godotenv.ParseWithOptions(strings.NewReader("FOO=$(sleep 5)"), opts)
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected command to time out, got %v", err)
+	}
+}
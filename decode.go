@@ -0,0 +1,310 @@
+package godotenv
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRequired is held by a FieldError for fields tagged with required:"true" that are not set.
+var ErrRequired = errors.New("required variable is not set")
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	urlType             = reflect.TypeOf(url.URL{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// FieldError describes why a struct field could not be decoded.
+type FieldError struct {
+	// Field is the path to the field, e.g. Database.Port.
+	Field string
+	// Key is the name of the variable the field is decoded from.
+	Key string
+	// Value is the value that could not be decoded.
+	Value string
+	Err   error
+}
+
+func (e FieldError) Error() string {
+	if errors.Is(e.Err, ErrRequired) {
+		return fmt.Sprintf("godotenv: %s: %s is required but not set", e.Field, e.Key)
+	}
+
+	return fmt.Sprintf("godotenv: %s: cannot decode %s=%q: %v", e.Field, e.Key, e.Value, e.Err)
+}
+
+func (e FieldError) Unwrap() error {
+	return e.Err
+}
+
+// DecodeErrors is returned by Decode and holds a FieldError for every field that could not be decoded.
+// errors.As can be used to find the FieldError among them.
+type DecodeErrors []error
+
+func (e DecodeErrors) Error() string              { return multiError(e).Error() }
+func (e DecodeErrors) Unwrap() []error            { return e }
+func (e DecodeErrors) Is(target error) bool       { return multiError(e).Is(target) }
+func (e DecodeErrors) As(target interface{}) bool { return multiError(e).As(target) }
+
+// ReadInto reads the env files with Read, and decodes the variables into v using Decode. As with
+// Read, the last file takes precedence when a variable is defined in more than one file.
+func ReadInto(v interface{}, filenames ...string) error {
+	envMap, err := Read(filenames...)
+	if err != nil {
+		return err
+	}
+
+	return Decode(envMap, v)
+}
+
+// Decode fills the fields of the struct v points to from envMap, using the variable named by the env tag
+// of each field. Fields without an env tag are left alone, except for nested structs.
+//
+//	type Config struct {
+//		Port     int           `env:"PORT" default:"8080"`
+//		Hosts    []string      `env:"HOSTS" sep:";"`
+//		Timeout  time.Duration `env:"TIMEOUT"`
+//		Database struct {
+//			URL url.URL `env:"URL" required:"true"`
+//		} `env:"DB_"`
+//	}
+//
+// The following types are supported: strings, bools, all int, uint and float types, time.Duration, url.URL,
+// types implementing encoding.TextUnmarshaler, pointers to any of these, and slices and maps of these.
+// Integers are always decimal, so that e.g. 010 is decoded as 10 rather than 8. Pointers are left nil
+// when the variable is empty.
+//
+// The following tags can be used:
+//
+//	env       the name of the variable, or the prefix for the fields of a nested struct; "-" skips the field
+//	default   the value used if the variable is not set
+//	required  "true" to return an error if the variable is not set and has no default
+//	sep       the separator between the elements of a slice or map, defaults to ","
+//	kvsep     the separator between the key and value of a map element, defaults to ":"
+//
+// Decoding doesn't stop at the first problem, instead all fields that could not be decoded are returned
+// as DecodeErrors.
+func Decode(envMap map[string]string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("godotenv: Decode requires a non-nil pointer to a struct, got %T", v)
+	}
+
+	d := decoder{envMap: envMap}
+	d.decodeStruct(rv.Elem(), "", "")
+
+	if len(d.errs) != 0 {
+		return d.errs
+	}
+
+	return nil
+}
+
+type decoder struct {
+	envMap map[string]string
+	errs   DecodeErrors
+}
+
+func (d *decoder) decodeStruct(rv reflect.Value, prefix, path string) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+
+		name, tagged := field.Tag.Lookup("env")
+		if name == "-" {
+			continue
+		}
+
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		fv := rv.Field(i)
+		if isNestedStruct(field.Type) {
+			if field.Type.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv.Set(reflect.New(field.Type.Elem()))
+				}
+				fv = fv.Elem()
+			}
+
+			d.decodeStruct(fv, prefix+name, fieldPath)
+			continue
+		}
+
+		if !tagged {
+			continue
+		}
+
+		key := prefix + name
+		value, exists := d.envMap[key]
+		if !exists {
+			value, exists = field.Tag.Lookup("default")
+		}
+
+		if !exists {
+			if field.Tag.Get("required") == "true" {
+				d.errs = append(d.errs, FieldError{Field: fieldPath, Key: key, Err: ErrRequired})
+			}
+			continue
+		}
+
+		if err := decodeValue(fv, value, field.Tag); err != nil {
+			d.errs = append(d.errs, FieldError{Field: fieldPath, Key: key, Value: value, Err: err})
+		}
+	}
+}
+
+// isNestedStruct reports whether fields of type t hold fields that should be decoded themselves.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct && t != urlType && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func decodeValue(rv reflect.Value, value string, tag reflect.StructTag) error {
+	if rv.Kind() == reflect.Ptr {
+		// an empty value leaves the pointer nil, which is how MarshalStruct outputs nil pointers
+		if value == "" {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+
+		elem := reflect.New(rv.Type().Elem())
+		if err := decodeValue(elem.Elem(), value, tag); err != nil {
+			return err
+		}
+
+		rv.Set(elem)
+		return nil
+	}
+
+	if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(value))
+	}
+
+	switch rv.Type() {
+	case durationType:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		rv.SetInt(int64(d))
+		return nil
+	case urlType:
+		u, err := url.Parse(value)
+		if err != nil {
+			return err
+		}
+
+		rv.Set(reflect.ValueOf(*u))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		rv.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		rv.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		u, err := strconv.ParseUint(value, 10, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, rv.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(f)
+	case reflect.Slice:
+		return decodeSlice(rv, value, tag)
+	case reflect.Map:
+		return decodeMap(rv, value, tag)
+	default:
+		return fmt.Errorf("unsupported type %s", rv.Type())
+	}
+
+	return nil
+}
+
+func decodeSlice(rv reflect.Value, value string, tag reflect.StructTag) error {
+	if value == "" {
+		rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+		return nil
+	}
+
+	elems := strings.Split(value, tagOrDefault(tag, "sep", ","))
+	slice := reflect.MakeSlice(rv.Type(), len(elems), len(elems))
+	for i, elem := range elems {
+		if err := decodeValue(slice.Index(i), strings.TrimSpace(elem), ""); err != nil {
+			return fmt.Errorf("element %d: %w", i, err)
+		}
+	}
+
+	rv.Set(slice)
+	return nil
+}
+
+func decodeMap(rv reflect.Value, value string, tag reflect.StructTag) error {
+	m := reflect.MakeMap(rv.Type())
+	if value == "" {
+		rv.Set(m)
+		return nil
+	}
+
+	kvsep := tagOrDefault(tag, "kvsep", ":")
+	for _, pair := range strings.Split(value, tagOrDefault(tag, "sep", ",")) {
+		kv := strings.SplitN(pair, kvsep, 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("map element %q is missing the key value separator %q", pair, kvsep)
+		}
+
+		k := reflect.New(rv.Type().Key()).Elem()
+		if err := decodeValue(k, strings.TrimSpace(kv[0]), ""); err != nil {
+			return fmt.Errorf("map key %q: %w", kv[0], err)
+		}
+
+		v := reflect.New(rv.Type().Elem()).Elem()
+		if err := decodeValue(v, strings.TrimSpace(kv[1]), ""); err != nil {
+			return fmt.Errorf("map value for %q: %w", kv[0], err)
+		}
+
+		m.SetMapIndex(k, v)
+	}
+
+	rv.Set(m)
+	return nil
+}
+
+func tagOrDefault(tag reflect.StructTag, key, def string) string {
+	if value, ok := tag.Lookup(key); ok && value != "" {
+		return value
+	}
+
+	return def
+}
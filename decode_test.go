@@ -0,0 +1,157 @@
+package godotenv_test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+type decodeDatabase struct {
+	URL      url.URL `env:"URL" required:"true"`
+	MaxConns uint16  `env:"MAX_CONNS" default:"10"`
+}
+
+type decodeConfig struct {
+	Name     string            `env:"NAME"`
+	Port     int               `env:"PORT" default:"8080"`
+	Debug    bool              `env:"DEBUG"`
+	Ratio    float64           `env:"RATIO"`
+	Timeout  time.Duration     `env:"TIMEOUT"`
+	Hosts    []string          `env:"HOSTS" sep:";"`
+	Ports    []int             `env:"PORTS"`
+	Labels   map[string]string `env:"LABELS"`
+	Weights  map[string]int    `env:"WEIGHTS" sep:";" kvsep:"="`
+	IP       net.IP            `env:"IP"`
+	Optional *int              `env:"OPTIONAL"`
+	Database decodeDatabase    `env:"DB_"`
+	Replica  *decodeDatabase   `env:"REPLICA_"`
+	Skipped  string            `env:"-"`
+	Untagged string
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	envMap := map[string]string{
+		"NAME":              "app",
+		"DEBUG":             "true",
+		"RATIO":             "0.5",
+		"TIMEOUT":           "1m30s",
+		"HOSTS":             "a.example.com;b.example.com",
+		"PORTS":             "80, 443",
+		"LABELS":            "team:core,tier:1",
+		"WEIGHTS":           "a=1;b=2",
+		"IP":                "10.0.0.1",
+		"OPTIONAL":          "08",
+		"DB_URL":            "postgres://localhost:5432/app",
+		"REPLICA_URL":       "postgres://replica:5432/app",
+		"REPLICA_MAX_CONNS": "010",
+		"Untagged":          "ignored",
+		"-":                 "ignored",
+	}
+
+	var cfg decodeConfig
+	if err := godotenv.Decode(envMap, &cfg); err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+
+	optional := 8
+	expected := decodeConfig{
+		Name:     "app",
+		Port:     8080,
+		Debug:    true,
+		Ratio:    0.5,
+		Timeout:  90 * time.Second,
+		Hosts:    []string{"a.example.com", "b.example.com"},
+		Ports:    []int{80, 443},
+		Labels:   map[string]string{"team": "core", "tier": "1"},
+		Weights:  map[string]int{"a": 1, "b": 2},
+		IP:       net.ParseIP("10.0.0.1"),
+		Optional: &optional,
+		Database: decodeDatabase{
+			URL:      url.URL{Scheme: "postgres", Host: "localhost:5432", Path: "/app"},
+			MaxConns: 10,
+		},
+		Replica: &decodeDatabase{
+			URL:      url.URL{Scheme: "postgres", Host: "replica:5432", Path: "/app"},
+			MaxConns: 10,
+		},
+	}
+
+	if !reflect.DeepEqual(expected, cfg) {
+		t.Errorf("Expected %+v, got %+v", expected, cfg)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	envMap := map[string]string{
+		"PORT":              "http",
+		"DEBUG":             "maybe",
+		"PORTS":             "80,0x1bb",
+		"LABELS":            "team",
+		"REPLICA_URL":       "postgres://replica/app",
+		"REPLICA_MAX_CONNS": "70000",
+	}
+
+	var cfg decodeConfig
+	err := godotenv.Decode(envMap, &cfg)
+
+	var decodeErrs godotenv.DecodeErrors
+	if !errors.As(err, &decodeErrs) {
+		t.Fatalf("Expected DecodeErrors, got %v", err)
+	}
+
+	expected := []string{"Port", "Debug", "Ports", "Labels", "Database.URL", "Replica.MaxConns"}
+	fields := make([]string, len(decodeErrs))
+	for i, err := range decodeErrs {
+		var fieldErr godotenv.FieldError
+		if !errors.As(err, &fieldErr) {
+			t.Fatalf("Expected a FieldError, got %v", err)
+		}
+		fields[i] = fieldErr.Field
+	}
+	if !reflect.DeepEqual(expected, fields) {
+		t.Errorf("Expected errors for %v, got %v", expected, fields)
+	}
+
+	if !errors.Is(err, godotenv.ErrRequired) {
+		t.Errorf("Expected ErrRequired for DB_URL, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "godotenv: Database.URL: DB_URL is required but not set") {
+		t.Errorf("Expected the error to mention DB_URL, got %v", err)
+	}
+}
+
+func TestDecodeRequiresStructPointer(t *testing.T) {
+	t.Parallel()
+
+	var cfg decodeConfig
+	if err := godotenv.Decode(map[string]string{}, cfg); err == nil {
+		t.Error("Expected an error when not passing a pointer")
+	}
+}
+
+func TestReadInto(t *testing.T) {
+	t.Parallel()
+
+	var cfg struct {
+		A int    `env:"OPTION_A"`
+		B string `env:"OPTION_B"`
+		C *bool  `env:"OPTION_D"`
+	}
+	if err := godotenv.ReadInto(&cfg, "fixtures/plain.env"); err != nil {
+		t.Fatalf("Error reading: %s", err)
+	}
+
+	if cfg.A != 1 || cfg.B != "2" || cfg.C != nil {
+		t.Errorf("Unexpected config: %+v", cfg)
+	}
+}
@@ -0,0 +1,309 @@
+package godotenv
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+)
+
+// QuoteStyle describes how a value was quoted in the source.
+type QuoteStyle uint8
+
+const (
+	// QuoteNone is used for values that are not quoted.
+	QuoteNone QuoteStyle = iota
+	// QuoteSingle is used for values that start with a single quote.
+	QuoteSingle
+	// QuoteDouble is used for values that start with a double quote.
+	QuoteDouble
+)
+
+// NodeKind identifies the type of statement a Node holds.
+type NodeKind uint8
+
+const (
+	// BlankNode is a line that holds nothing but whitespace.
+	BlankNode NodeKind = iota
+	// CommentNode is a line that holds a comment and no assignment.
+	CommentNode
+	// EntryNode is a KEY=VALUE assignment, which may span multiple lines.
+	EntryNode
+)
+
+// Entry is a single KEY=VALUE assignment in a Document.
+type Entry struct {
+	Key string
+	// Value is the value after quotes, escapes and expansions have been resolved.
+	Value    string
+	Exported bool
+	Quote    QuoteStyle
+	// Comment is the inline comment following the value, without the leading '#'.
+	Comment string
+	// Line is the line the entry starts on. It is zero for entries added with Set.
+	Line int
+}
+
+// Node is a single statement in a Document.
+type Node struct {
+	Kind NodeKind
+	// Raw holds the bytes of the node exactly as they appear in the source, including the line ending.
+	Raw []byte
+	// Entry is only set when Kind is EntryNode.
+	Entry *Entry
+
+	// valueStart and valueEnd delimit the value in Raw, so that Set can replace it while
+	// keeping the key, export prefix and inline comment intact.
+	valueStart, valueEnd int
+}
+
+// Document is an ordered representation of an env file that retains comments, blank lines,
+// quoting and export prefixes. Nodes that are not modified are written back byte-for-byte,
+// which makes it suitable for programmatically editing env files.
+type Document struct {
+	Nodes []*Node
+}
+
+// ParseDocument reads an env file from io.Reader, returning a Document.
+func ParseDocument(r io.Reader) (*Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDocument(data, "")
+}
+
+// ReadDocument reads an env file from a file, returning a Document.
+func ReadDocument(filename string) (*Document, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseDocument(data, filename)
+}
+
+func parseDocument(data []byte, filename string) (*Document, error) {
+	doc := &Document{}
+	p := newParser(data, ParseOptions{})
+	p.filename = filename
+	p.doc = doc
+
+	if _, err := p.parseWithLookup(LookupEnv); err != nil {
+		return nil, err
+	}
+
+	return doc, nil
+}
+
+// Get returns the value of the last entry with the given key, and whether it exists.
+func (d *Document) Get(key string) (string, bool) {
+	if n := d.lookup(key); n != nil {
+		return n.Entry.Value, true
+	}
+
+	return "", false
+}
+
+// Set sets the value of the last entry with the given key. The original quote style is kept
+// if it can hold the value without it being altered on the next read. If the key doesn't
+// exist, a new entry is appended to the end of the document.
+func (d *Document) Set(key, value string) {
+	if n := d.lookup(key); n != nil {
+		formatted, quote := formatValue(value, n.Entry.Quote)
+
+		raw := make([]byte, 0, n.valueStart+len(formatted)+len(n.Raw)-n.valueEnd)
+		raw = append(raw, n.Raw[:n.valueStart]...)
+		raw = append(raw, formatted...)
+		raw = append(raw, n.Raw[n.valueEnd:]...)
+
+		n.Raw = raw
+		n.valueEnd = n.valueStart + len(formatted)
+		n.Entry.Value = value
+		n.Entry.Quote = quote
+		return
+	}
+
+	if len(d.Nodes) > 0 {
+		if last := d.Nodes[len(d.Nodes)-1]; !bytes.HasSuffix(last.Raw, []byte("\n")) {
+			last.Raw = append(last.Raw[:len(last.Raw):len(last.Raw)], '\n')
+		}
+	}
+
+	formatted, quote := formatValue(value, QuoteNone)
+	d.Nodes = append(d.Nodes, &Node{
+		Kind:       EntryNode,
+		Raw:        []byte(key + "=" + formatted + "\n"),
+		Entry:      &Entry{Key: key, Value: value, Quote: quote},
+		valueStart: len(key) + 1,
+		valueEnd:   len(key) + 1 + len(formatted),
+	})
+}
+
+// Delete removes all entries with the given key.
+func (d *Document) Delete(key string) {
+	nodes := d.Nodes[:0]
+	for _, n := range d.Nodes {
+		if n.Kind != EntryNode || n.Entry.Key != key {
+			nodes = append(nodes, n)
+		}
+	}
+
+	for i := len(nodes); i < len(d.Nodes); i++ {
+		d.Nodes[i] = nil
+	}
+	d.Nodes = nodes
+}
+
+// Entries returns all entries in the order they appear in the document.
+func (d *Document) Entries() []*Entry {
+	entries := make([]*Entry, 0, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if n.Kind == EntryNode {
+			entries = append(entries, n.Entry)
+		}
+	}
+
+	return entries
+}
+
+// Map returns the entries of the document as a map of keys and values.
+func (d *Document) Map() map[string]string {
+	envMap := make(map[string]string, len(d.Nodes))
+	for _, n := range d.Nodes {
+		if n.Kind == EntryNode {
+			envMap[n.Entry.Key] = n.Entry.Value
+		}
+	}
+
+	return envMap
+}
+
+// WriteTo writes the document to w. Nodes that have not been modified are written exactly
+// as they were read.
+func (d *Document) WriteTo(w io.Writer) (n int64, err error) {
+	for _, node := range d.Nodes {
+		c, err := w.Write(node.Raw)
+		n += int64(c)
+		if err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// String returns the document as it would be written by WriteTo.
+func (d *Document) String() string {
+	var sb strings.Builder
+	_, _ = d.WriteTo(&sb)
+	return sb.String()
+}
+
+func (d *Document) lookup(key string) *Node {
+	for i := len(d.Nodes) - 1; i >= 0; i-- {
+		if n := d.Nodes[i]; n.Kind == EntryNode && n.Entry.Key == key {
+			return n
+		}
+	}
+
+	return nil
+}
+
+func (d *Document) appendRaw(raw []byte) {
+	kind := BlankNode
+	if bytes.IndexByte(raw, '#') != -1 {
+		kind = CommentNode
+	}
+
+	d.Nodes = append(d.Nodes, &Node{Kind: kind, Raw: raw})
+}
+
+func (d *Document) appendEntry(raw []byte, entry *Entry, valueStart, commentStart int) {
+	end := len(raw)
+	switch {
+	case bytes.HasSuffix(raw, []byte("\r\n")):
+		end -= 2
+	case bytes.HasSuffix(raw, []byte("\n")), bytes.HasSuffix(raw, []byte("\r")):
+		end--
+	}
+
+	if commentStart != -1 {
+		entry.Comment = strings.TrimSpace(string(raw[commentStart+1 : end]))
+		end = commentStart
+	}
+
+	for end > valueStart && (raw[end-1] == ' ' || raw[end-1] == '\t') {
+		end--
+	}
+
+	d.Nodes = append(d.Nodes, &Node{
+		Kind:       EntryNode,
+		Raw:        raw,
+		Entry:      entry,
+		valueStart: valueStart,
+		valueEnd:   end,
+	})
+}
+
+// formatValue formats the value so that it is read back unaltered, using the preferred quote
+// style if possible. It returns the formatted value and the quote style used. Empty values are
+// always quoted, so that they can be followed by a comment.
+func formatValue(value string, preferred QuoteStyle) (string, QuoteStyle) {
+	switch {
+	case preferred == QuoteNone && value != "" && canLeaveUnquoted(value):
+		return value, QuoteNone
+	case preferred != QuoteDouble && canSingleQuote(value):
+		return "'" + value + "'", QuoteSingle
+	default:
+		return doubleQuote(value), QuoteDouble
+	}
+}
+
+// canLeaveUnquoted reports whether the value can be written without quotes.
+func canLeaveUnquoted(value string) bool {
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '\'', '"', '$', '#':
+			return false
+		default:
+			if c <= ' ' || c == 0x7f {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// canSingleQuote reports whether the value can be written in single quotes. Single quoted
+// values cannot contain single quotes, and a trailing backslash would escape the closing quote.
+func canSingleQuote(value string) bool {
+	return !strings.Contains(value, "'") && !strings.HasSuffix(value, `\`)
+}
+
+// doubleQuote wraps the value in double quotes, escaping anything that would otherwise be
+// interpreted by the parser.
+func doubleQuote(value string) string {
+	var sb strings.Builder
+	sb.Grow(len(value) + 2)
+
+	sb.WriteByte('"')
+	for i := 0; i < len(value); i++ {
+		switch c := value[i]; c {
+		case '\\', '"', '$':
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+
+	return sb.String()
+}
@@ -0,0 +1,191 @@
+package godotenv_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestDocumentRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	fixtures := []string{"all.env", "comments.env", "equals.env", "exported.env", "plain.env", "quoted.env", "substitutions.env"}
+	for _, fixture := range fixtures {
+		fixture := fixture
+		t.Run(fixture, func(t *testing.T) {
+			t.Parallel()
+
+			fixtureFilename := fmt.Sprintf("fixtures/%s", fixture)
+			expected, err := os.ReadFile(fixtureFilename)
+			if err != nil {
+				t.Fatalf("Error reading %s: %v", fixtureFilename, err)
+			}
+
+			doc, err := godotenv.ReadDocument(fixtureFilename)
+			if err != nil {
+				t.Fatalf("Expected '%s' to read without error (%v)", fixtureFilename, err)
+			}
+
+			var buf bytes.Buffer
+			if _, err = doc.WriteTo(&buf); err != nil {
+				t.Fatalf("Expected '%s' to write without error (%v)", fixtureFilename, err)
+			}
+
+			if !bytes.Equal(expected, buf.Bytes()) {
+				t.Errorf("Expected '%s' to roundtrip as %q, got %q instead", fixtureFilename, expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestDocumentEntries(t *testing.T) {
+	t.Parallel()
+
+	doc, err := godotenv.ReadDocument("fixtures/comments.env")
+	if err != nil {
+		t.Fatalf("Error reading document: %v", err)
+	}
+
+	expected := []godotenv.Entry{
+		{Key: "DB_HOST", Value: "localhost", Exported: true, Comment: "inline comment", Line: 2},
+		{Key: "DB_USER", Value: "admin", Quote: godotenv.QuoteSingle, Line: 4},
+		{Key: "DB_PASS", Value: "s3cr3t", Quote: godotenv.QuoteDouble, Line: 5},
+		{Key: "APP_URL", Value: "http://localhost:8080", Quote: godotenv.QuoteDouble, Line: 8},
+	}
+
+	entries := doc.Entries()
+	if len(entries) != len(expected) {
+		t.Fatalf("Expected %d entries, got %d", len(expected), len(entries))
+	}
+
+	for i, entry := range entries {
+		if *entry != expected[i] {
+			t.Errorf("Expected entry %d to be %+v, got %+v", i, expected[i], *entry)
+		}
+	}
+
+	kinds := []godotenv.NodeKind{
+		godotenv.CommentNode, godotenv.EntryNode, godotenv.BlankNode, godotenv.EntryNode,
+		godotenv.EntryNode, godotenv.CommentNode, godotenv.BlankNode, godotenv.EntryNode,
+	}
+	if len(doc.Nodes) != len(kinds) {
+		t.Fatalf("Expected %d nodes, got %d", len(kinds), len(doc.Nodes))
+	}
+	for i, node := range doc.Nodes {
+		if node.Kind != kinds[i] {
+			t.Errorf("Expected node %d (%q) to be of kind %d, got %d", i, node.Raw, kinds[i], node.Kind)
+		}
+	}
+}
+
+func TestDocumentEditing(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		edit     func(doc *godotenv.Document)
+		expected string
+	}{
+		{
+			name:     "set keeps export prefix and comment",
+			input:    "# head\nexport FOO=bar   # the foo\nBAZ=1\n",
+			edit:     func(doc *godotenv.Document) { doc.Set("FOO", "qux") },
+			expected: "# head\nexport FOO=qux   # the foo\nBAZ=1\n",
+		},
+		{
+			name:     "set keeps single quotes",
+			input:    "FOO='bar'\n",
+			edit:     func(doc *godotenv.Document) { doc.Set("FOO", "hello world") },
+			expected: "FOO='hello world'\n",
+		},
+		{
+			name:     "set keeps double quotes",
+			input:    "FOO=\"bar\"\r\nBAR=1\r\n",
+			edit:     func(doc *godotenv.Document) { doc.Set("FOO", "baz") },
+			expected: "FOO=\"baz\"\r\nBAR=1\r\n",
+		},
+		{
+			name:     "set quotes values that need it",
+			input:    "FOO=bar\n",
+			edit:     func(doc *godotenv.Document) { doc.Set("FOO", "it's $HOME") },
+			expected: "FOO=\"it's \\$HOME\"\n",
+		},
+		{
+			name:     "set replaces multi-line values",
+			input:    "FOO=\"a\nb\" # comment\nBAR=1\n",
+			edit:     func(doc *godotenv.Document) { doc.Set("FOO", "c") },
+			expected: "FOO=\"c\" # comment\nBAR=1\n",
+		},
+		{
+			name:  "set empty value keeps comment",
+			input: "FOO=bar # the foo\nBAR='baz' # the bar\n",
+			edit: func(doc *godotenv.Document) {
+				doc.Set("FOO", "")
+				doc.Set("BAR", "")
+			},
+			expected: "FOO='' # the foo\nBAR='' # the bar\n",
+		},
+		{
+			name:     "set appends new keys",
+			input:    "FOO=bar",
+			edit:     func(doc *godotenv.Document) { doc.Set("BAZ", "a b") },
+			expected: "FOO=bar\nBAZ='a b'\n",
+		},
+		{
+			name:     "delete removes all entries with the key",
+			input:    "FOO=1\n# keep me\nBAR=2\nFOO=3\n",
+			edit:     func(doc *godotenv.Document) { doc.Delete("FOO") },
+			expected: "# keep me\nBAR=2\n",
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			doc, err := godotenv.ParseDocument(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("Error parsing document: %v", err)
+			}
+
+			tt.edit(doc)
+
+			actual := doc.String()
+			if actual != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, actual)
+			}
+
+			reparsed, err := godotenv.Unmarshal(actual)
+			if err != nil {
+				t.Fatalf("Error parsing edited document: %v", err)
+			}
+			for k, v := range doc.Map() {
+				if reparsed[k] != v {
+					t.Errorf("Expected %s to read back as %q, got %q", k, v, reparsed[k])
+				}
+			}
+		})
+	}
+}
+
+func TestDocumentGet(t *testing.T) {
+	t.Parallel()
+
+	doc, err := godotenv.ParseDocument(strings.NewReader("FOO=1\nFOO=2\n"))
+	if err != nil {
+		t.Fatalf("Error parsing document: %v", err)
+	}
+
+	if v, ok := doc.Get("FOO"); !ok || v != "2" {
+		t.Errorf("Expected FOO to be %q, got %q (exists: %v)", "2", v, ok)
+	}
+
+	if _, ok := doc.Get("BAR"); ok {
+		t.Error("Expected BAR to not exist")
+	}
+}
@@ -0,0 +1,171 @@
+package godotenv
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// MarshalStruct outputs the fields of the struct v, or the struct v points to, as a dotenv-formatted
+// environment file. It is the counterpart of Decode and uses the same tags, so that the output can be
+// decoded back into the same struct. Values are quoted the same way as Marshal does, and the desc tag
+// of a field is added as a comment above the variable, e.g.
+//
+//	type Config struct {
+//		Port int `env:"PORT" desc:"The port to listen on."`
+//	}
+//
+// outputs
+//
+//	# The port to listen on.
+//	PORT='8080'
+//
+// The variables are in the same order as the fields. Nil pointers are output as empty values,
+// which Decode reads back as nil.
+func MarshalStruct(v interface{}) (string, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return "", fmt.Errorf("godotenv: MarshalStruct requires a struct or a pointer to a struct, got %T", v)
+	}
+
+	var lines []string
+	if err := marshalStruct(rv, "", &lines); err != nil {
+		return "", err
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
+func marshalStruct(rv reflect.Value, prefix string, lines *[]string) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+
+		name, tagged := field.Tag.Lookup("env")
+		if name == "-" {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if isNestedStruct(field.Type) {
+			if field.Type.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					fv = reflect.New(field.Type.Elem())
+				}
+				fv = fv.Elem()
+			}
+
+			if err := marshalStruct(fv, prefix+name, lines); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !tagged {
+			continue
+		}
+
+		value, err := encodeValue(fv, field.Tag)
+		if err != nil {
+			return fmt.Errorf("godotenv: %s.%s: %w", rt.Name(), field.Name, err)
+		}
+
+		if desc := field.Tag.Get("desc"); desc != "" {
+			for _, line := range strings.Split(desc, "\n") {
+				*lines = append(*lines, strings.TrimRight("# "+line, " "))
+			}
+		}
+
+		*lines = append(*lines, prefix+name+"="+marshalValue(value))
+	}
+
+	return nil
+}
+
+func encodeValue(rv reflect.Value, tag reflect.StructTag) (string, error) {
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", nil
+		}
+
+		return encodeValue(rv.Elem(), tag)
+	}
+
+	if rv.Type().Implements(textMarshalerType) || reflect.PtrTo(rv.Type()).Implements(textMarshalerType) {
+		if !rv.CanAddr() {
+			addressable := reflect.New(rv.Type()).Elem()
+			addressable.Set(rv)
+			rv = addressable
+		}
+
+		text, err := rv.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch rv.Type() {
+	case durationType:
+		return time.Duration(rv.Int()).String(), nil
+	case urlType:
+		u := rv.Interface().(url.URL)
+		return u.String(), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, rv.Type().Bits()), nil
+	case reflect.Slice:
+		elems := make([]string, rv.Len())
+		for i := range elems {
+			elem, err := encodeValue(rv.Index(i), "")
+			if err != nil {
+				return "", fmt.Errorf("element %d: %w", i, err)
+			}
+			elems[i] = elem
+		}
+
+		return strings.Join(elems, tagOrDefault(tag, "sep", ",")), nil
+	case reflect.Map:
+		kvsep := tagOrDefault(tag, "kvsep", ":")
+		pairs := make([]string, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			k, err := encodeValue(iter.Key(), "")
+			if err != nil {
+				return "", fmt.Errorf("map key: %w", err)
+			}
+
+			v, err := encodeValue(iter.Value(), "")
+			if err != nil {
+				return "", fmt.Errorf("map value for %q: %w", k, err)
+			}
+
+			pairs = append(pairs, k+kvsep+v)
+		}
+		sort.Strings(pairs)
+
+		return strings.Join(pairs, tagOrDefault(tag, "sep", ",")), nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", rv.Type())
+	}
+}
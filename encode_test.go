@@ -0,0 +1,115 @@
+package godotenv_test
+
+import (
+	"net"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestMarshalStruct(t *testing.T) {
+	t.Parallel()
+
+	cfg := struct {
+		Port     int           `env:"PORT" desc:"The port to listen on."`
+		Name     string        `env:"NAME" desc:"The name of the service.\nShown in the logs."`
+		Timeout  time.Duration `env:"TIMEOUT"`
+		Hosts    []string      `env:"HOSTS" sep:";"`
+		Optional *int          `env:"OPTIONAL"`
+		Database struct {
+			URL url.URL `env:"URL"`
+		} `env:"DB_"`
+		Untagged string
+	}{
+		Port:    8080,
+		Name:    "my app",
+		Timeout: 90 * time.Second,
+		Hosts:   []string{"a", "b"},
+	}
+	cfg.Database.URL = url.URL{Scheme: "postgres", Host: "localhost", Path: "/app"}
+
+	expected := `# The port to listen on.
+PORT='8080'
+# The name of the service.
+# Shown in the logs.
+NAME='my app'
+TIMEOUT='1m30s'
+HOSTS='a;b'
+OPTIONAL=''
+DB_URL='postgres://localhost/app'`
+
+	actual, err := godotenv.MarshalStruct(&cfg)
+	if err != nil {
+		t.Fatalf("Error marshalling: %s", err)
+	}
+	if actual != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, actual)
+	}
+}
+
+func TestMarshalStructRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	optional := 7
+	cfg := decodeConfig{
+		Name:     `it's "quoted"`,
+		Port:     443,
+		Debug:    true,
+		Ratio:    0.25,
+		Timeout:  time.Minute,
+		Hosts:    []string{"a.example.com", "b.example.com"},
+		Ports:    []int{80, 443},
+		Labels:   map[string]string{"team": "core", "tier": "1"},
+		Weights:  map[string]int{"a": 1, "b": 2},
+		IP:       net.ParseIP("10.0.0.1"),
+		Optional: &optional,
+		Database: decodeDatabase{
+			URL:      url.URL{Scheme: "postgres", Host: "localhost:5432", Path: "/app"},
+			MaxConns: 10,
+		},
+		Replica: &decodeDatabase{
+			URL:      url.URL{Scheme: "postgres", Host: "replica:5432", Path: "/app"},
+			MaxConns: 2,
+		},
+	}
+
+	nilPointer := cfg
+	nilPointer.Optional = nil
+
+	tests := []struct {
+		name string
+		cfg  decodeConfig
+	}{
+		{name: "all set", cfg: cfg},
+		{name: "nil pointer", cfg: nilPointer},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			marshalled, err := godotenv.MarshalStruct(tt.cfg)
+			if err != nil {
+				t.Fatalf("Error marshalling: %s", err)
+			}
+
+			envMap, err := godotenv.Unmarshal(marshalled)
+			if err != nil {
+				t.Fatalf("Error unmarshalling %q: %s", marshalled, err)
+			}
+
+			var actual decodeConfig
+			if err = godotenv.Decode(envMap, &actual); err != nil {
+				t.Fatalf("Error decoding: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.cfg, actual) {
+				t.Errorf("Expected %+v, got %+v", tt.cfg, actual)
+			}
+		})
+	}
+}
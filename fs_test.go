@@ -0,0 +1,77 @@
+package godotenv_test
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+//go:embed fixtures/plain.env fixtures/invalid1.env
+var fixturesFS embed.FS
+
+func TestReadFS(t *testing.T) {
+	t.Parallel()
+
+	envMap, err := godotenv.ReadFS(fixturesFS, "fixtures/plain.env")
+	if err != nil {
+		t.Fatalf("Error reading file: %s", err)
+	}
+
+	expectedValues := map[string]string{
+		"OPTION_A": "1",
+		"OPTION_B": "2",
+		"OPTION_C": "",
+	}
+	if !reflect.DeepEqual(expectedValues, envMap) {
+		t.Errorf("Mismatch env vars")
+		printDiff(t, expectedValues, envMap)
+	}
+}
+
+func TestReadFSErrors(t *testing.T) {
+	t.Parallel()
+
+	_, err := godotenv.ReadFS(fixturesFS, "fixtures/missing.env")
+	if !errors.Is(err, fs.ErrNotExist) || !strings.Contains(err.Error(), "fixtures/missing.env") {
+		t.Errorf("Expected a not exist error for fixtures/missing.env, got %v", err)
+	}
+
+	_, err = godotenv.ReadFS(fixturesFS, "fixtures/invalid1.env")
+
+	var parseErr godotenv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Filename != "fixtures/invalid1.env" {
+		t.Errorf("Expected a ParseError for fixtures/invalid1.env, got %v", err)
+	}
+}
+
+func TestLoadFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		".env":       {Data: []byte("A=1\nB=${A}2\n")},
+		"prod/.env":  {Data: []byte("A=3\n")},
+		"unused.env": {Data: []byte("C=4\n")},
+	}
+
+	os.Clearenv()
+	if err := godotenv.LoadFS(fsys); err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+	if a, b := os.Getenv("A"), os.Getenv("B"); a != "1" || b != "12" {
+		t.Errorf("Expected A=1 and B=12, got A=%s and B=%s", a, b)
+	}
+
+	os.Clearenv()
+	err := godotenv.LoadWithOptions(godotenv.LoadOptions{FS: fsys, Overload: true, Optional: []string{"prod/.env.local"}}, ".env", "prod/.env", "prod/.env.local")
+	if err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+	if a, c := os.Getenv("A"), os.Getenv("C"); a != "3" || c != "" {
+		t.Errorf("Expected A=3 and C to be unset, got A=%s and C=%s", a, c)
+	}
+}
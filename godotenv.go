@@ -12,11 +12,13 @@
 package godotenv
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"sort"
-	"strconv"
 	"strings"
 )
 
@@ -28,8 +30,9 @@ import (
 //		godotenv.Load("fileone", "filetwo")
 //
 // It's important to note that it WILL NOT OVERRIDE an env variable that already exists - consider the .env file to set dev vars or sensible defaults
+// When a variable is defined in more than one file, the first file takes precedence.
 func Load(filenames ...string) (err error) {
-	return loadFile(filenames, false)
+	return loadFile(filenames, LoadOptions{})
 }
 
 // Overload will read your env file(s) and load them into ENV for this process.
@@ -40,60 +43,121 @@ func Load(filenames ...string) (err error) {
 //		godotenv.Overload("fileone", "filetwo")
 //
 // It's important to note this WILL OVERRIDE an env variable that already exists - consider the .env file to forcefilly set all vars.
+// When a variable is defined in more than one file, the last file takes precedence.
 func Overload(filenames ...string) (err error) {
-	return loadFile(filenames, true)
+	return loadFile(filenames, LoadOptions{Overload: true})
 }
 
-// Read all env (with same file loading semantics as Load) but return values as
-// a map rather than automatically writing values into env
+// LoadWithOptions is like Load, but allows configuring how files are parsed and loaded
+// through opts. If opts.Overload is set it behaves like Overload.
+func LoadWithOptions(opts LoadOptions, filenames ...string) (err error) {
+	return loadFile(filenames, opts)
+}
+
+// Read all env but return values as a map rather than automatically writing values into env.
+// Unlike Load, variables already set in the environment don't take precedence, and when a
+// variable is defined in more than one file, the last file takes precedence.
 func Read(filenames ...string) (envMap map[string]string, err error) {
-	filenames = filenamesOrDefault(filenames)
-	envMap = make(map[string]string)
+	return ReadWithOptions(LoadOptions{}, filenames...)
+}
 
-	for _, filename := range filenames {
-		individualEnvMap, individualErr := readFile(filename)
+// ReadWithOptions is like Read, but allows configuring how files are parsed through opts.
+func ReadWithOptions(opts LoadOptions, filenames ...string) (envMap map[string]string, err error) {
+	merge := opts.MergeStrategy
+	if merge == nil {
+		merge = LastWins
+	}
+
+	sources, _, err := readFiles(filenamesOrDefault(filenames), opts, merge, nil)
+
+	envMap = make(map[string]string, len(sources))
+	for key, source := range sources {
+		envMap[key] = source.Value
+	}
+
+	return envMap, err
+}
+
+// LoadWithReport is like LoadWithOptions, but also returns a Report describing which variables
+// were set, and which were skipped because they already exist in the environment.
+func LoadWithReport(opts LoadOptions, filenames ...string) (Report, error) {
+	report, _, err := loadFiles(filenames, opts)
+	return report, err
+}
+
+// LoadFS is like Load, but reads the env files from fsys, e.g. an embed.FS.
+func LoadFS(fsys fs.FS, filenames ...string) error {
+	return loadFile(filenames, LoadOptions{FS: fsys})
+}
+
+// ReadFS is like Read, but reads the env files from fsys, e.g. an embed.FS.
+func ReadFS(fsys fs.FS, filenames ...string) (envMap map[string]string, err error) {
+	return ReadWithOptions(LoadOptions{FS: fsys}, filenames...)
+}
 
-		if individualErr != nil {
-			err = individualErr
-			return // return early on a spazout
+// ReadWithSources reports where the value of each variable comes from when loading the files
+// with LoadWithOptions, without changing the environment. This includes the file and line the
+// variable is defined on, the definitions it overrides, and whether the variable is already
+// set in the environment of the process.
+func ReadWithSources(opts LoadOptions, filenames ...string) (sources map[string]Source, err error) {
+	sources, _, err = readSources(opts, filenames)
+	return sources, err
+}
+
+// readSources is like ReadWithSources, but also returns the files that were read.
+func readSources(opts LoadOptions, filenames []string) (sources map[string]Source, read []string, err error) {
+	currentEnv := map[string]bool{}
+	for _, envLine := range os.Environ() {
+		key := strings.SplitN(envLine, "=", 2)[0]
+		currentEnv[key] = true
+	}
+
+	merge := opts.MergeStrategy
+	if merge == nil {
+		merge = FirstWins
+		if opts.Overload {
+			merge = LastWins
 		}
+	}
+
+	// variables that are already set are not loaded, so they shouldn't be visible to later files either
+	applies := func(key string) bool {
+		return !currentEnv[key] || opts.Overload
+	}
+
+	sources, read, err = readFiles(filenamesOrDefault(filenames), opts, merge, applies)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		for key, value := range individualEnvMap {
-			envMap[key] = value
+	for key, source := range sources {
+		if !applies(key) {
+			source.Overridden = append(source.Overridden, source.Definition)
+			source.Definition = Definition{Value: os.Getenv(key)}
+			source.FromEnv = true
+			sources[key] = source
 		}
 	}
 
-	return
+	return sources, read, nil
 }
 
 // ParseWithLookup reads an env file from io.Reader, returning a map of keys and values.
 // It uses the lookupEnv to retrieve environment variables. Parse calls this function with
 // LookupEnv as the lookupEnv argument.
 func ParseWithLookup(r io.Reader, lookupEnv lookupEnvFunc) (envMap map[string]string, err error) {
+	return ParseWithOptions(r, ParseOptions{LookupEnv: lookupEnv})
+}
+
+// ParseWithOptions reads an env file from io.Reader, returning a map of keys and values.
+// The options allow changing how the file is parsed, e.g. enabling strict mode.
+func ParseWithOptions(r io.Reader, opts ParseOptions) (envMap map[string]string, err error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
 
-	return parseWithLookup(data, lookupEnv)
-}
-
-func parseWithLookup(d []byte, lookupEnv lookupEnvFunc) (envMap map[string]string, err error) {
-	envMap = make(map[string]string)
-
-	expandEnv := func(s []byte) ([]byte, bool) {
-		if val, exists := envMap[string(s)]; exists {
-			return []byte(val), exists
-		}
-
-		return lookupEnv(s)
-	}
-
-	parser := newParser(d)
-
-	err = parser.parse(envMap, expandEnv)
-
-	return envMap, err
+	return newParser(data, opts).parseWithLookup(opts.lookupEnv())
 }
 
 // Parse reads an env file from io.Reader, returning a map of keys and values.
@@ -106,44 +170,99 @@ func Unmarshal(str string) (envMap map[string]string, err error) {
 	return Parse(strings.NewReader(str))
 }
 
-// Write serializes the given environment and writes it to a file
+// Write serializes the given environment and writes it to a file. The file is replaced atomically,
+// and new files are only readable by the current user. See WriteFile for details.
 func Write(envMap map[string]string, filename string) error {
-	content, err := Marshal(envMap)
-	if err != nil {
-		return err
-	}
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	_, err = file.WriteString(content + "\n")
-	if err != nil {
-		return err
-	}
-	return file.Sync()
+	return WriteFile(envMap, filename, WriteOptions{})
 }
 
 // Marshal outputs the given environment as a dotenv-formatted environment file.
-// Each line is in the format: KEY="VALUE" where VALUE is backslash-escaped.
+// Each line is in the format: KEY='VALUE', or KEY="VALUE" where VALUE is backslash-escaped if
+// it cannot be single quoted. Values are never altered, and Unmarshal reads them back as is.
 func Marshal(envMap map[string]string) (string, error) {
 	lines := make([]string, 0, len(envMap))
 	for k, v := range envMap {
-		if d, err := strconv.Atoi(v); err == nil {
-			lines = append(lines, fmt.Sprintf(`%s=%d`, k, d))
-		} else {
-			lines = append(lines, fmt.Sprintf(`%s=%s`, k, strconv.Quote(v)))
-		}
+		lines = append(lines, fmt.Sprintf(`%s=%s`, k, marshalValue(v)))
 	}
 	sort.Strings(lines)
 	return strings.Join(lines, "\n"), nil
 }
 
+// marshalValue quotes a single value for Marshal, so that it is read back unaltered. Single quotes
+// are used where possible, as nothing is expanded within them. Otherwise, the value is double quoted
+// with $, " and \ escaped. Line breaks are always escaped, so that each variable is on a single line.
+func marshalValue(v string) string {
+	if canSingleQuote(v) && !strings.ContainsAny(v, "\r\n") {
+		return "'" + v + "'"
+	}
+
+	return doubleQuote(v)
+}
+
 func LookupEnv(name []byte) (value []byte, exists bool) {
 	val, b := os.LookupEnv(string(name))
 	return []byte(val), b
 }
 
+// lookupEnvMap returns a lookupEnvFunc that looks up variables in envMap before falling back to lookupEnv.
+func lookupEnvMap(envMap map[string]string, lookupEnv lookupEnvFunc) lookupEnvFunc {
+	return func(name []byte) ([]byte, bool) {
+		if val, exists := envMap[string(name)]; exists {
+			return []byte(val), exists
+		}
+
+		return lookupEnv(name)
+	}
+}
+
+// LoadFromAncestors is like Load, but looks for the env files in the parent directories if they
+// are not found in the working directory. See LoadOptions.SearchAncestors for details.
+func LoadFromAncestors(filenames ...string) error {
+	return loadFile(filenames, LoadOptions{SearchAncestors: true})
+}
+
+// findInAncestors looks for filename in the working directory and its parent directories, stopping
+// at the first directory that holds a go.mod file or .git directory, or at the root. It returns the
+// path of the nearest file found, or filename itself if it isn't found or it's an absolute path.
+func findInAncestors(filename string) (string, error) {
+	if filepath.IsAbs(filename) {
+		return filename, nil
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		path := filepath.Join(dir, filename)
+		if _, err = os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			return path, nil
+		}
+
+		if isBoundary(dir) {
+			return filename, nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return filename, nil
+		}
+		dir = parent
+	}
+}
+
+// isBoundary reports whether dir is the root of a Go module or git repository.
+func isBoundary(dir string) bool {
+	for _, name := range []string{"go.mod", ".git"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
 func filenamesOrDefault(filenames []string) []string {
 	if len(filenames) == 0 {
 		return []string{".env"}
@@ -151,37 +270,162 @@ func filenamesOrDefault(filenames []string) []string {
 	return filenames
 }
 
-func loadFile(filenames []string, overload bool) error {
-	filenames = filenamesOrDefault(filenames)
+func loadFile(filenames []string, opts LoadOptions) error {
+	_, _, err := loadFiles(filenames, opts)
+	return err
+}
 
-	currentEnv := map[string]bool{}
-	for _, envLine := range os.Environ() {
-		key := strings.SplitN(envLine, "=", 2)[0]
-		currentEnv[key] = true
+// loadFiles loads the files into the environment, returning a report of the variables that were
+// loaded along with the files that were read.
+func loadFiles(filenames []string, opts LoadOptions) (report Report, read []string, err error) {
+	sources, read, err := readSources(opts, filenames)
+	if err != nil {
+		return Report{}, nil, err
+	}
+
+	keys := make([]string, 0, len(sources))
+	for key := range sources {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		source := sources[key]
+
+		if source.FromEnv {
+			report.Skipped = append(report.Skipped, ReportEntry{
+				Key:        key,
+				Definition: source.Overridden[len(source.Overridden)-1],
+				EnvValue:   source.Value,
+			})
+			continue
+		}
+
+		if opts.Snapshot != nil {
+			opts.Snapshot.record(key)
+		}
+
+		entry := ReportEntry{Key: key, Definition: source.Definition}
+		if envValue, exists := os.LookupEnv(key); exists {
+			entry.EnvValue = envValue
+			report.Overridden = append(report.Overridden, entry)
+		} else {
+			report.Applied = append(report.Applied, entry)
+		}
+
+		_ = os.Setenv(key, source.Value)
+	}
+
+	return report, read, nil
+}
+
+// readFiles reads the files in order, using merge to decide which definition is used for
+// variables that are defined in more than one file. Variables can be referenced once they're
+// defined, in which case the definition chosen by merge is used, if applies returns true for
+// them. Files that don't exist are skipped if they're listed in opts.Optional, or if
+// opts.IgnoreMissing is set. It also returns the files that were read, after searching the
+// parent directories if opts.SearchAncestors is set.
+func readFiles(filenames []string, opts LoadOptions, merge MergeStrategy, applies func(key string) bool) (sources map[string]Source, read []string, err error) {
+	sources = make(map[string]Source)
+
+	scope := make(map[string]string)
+	parseOpts := opts.ParseOptions
+	parseOpts.LookupEnv = lookupEnvMap(scope, parseOpts.lookupEnv())
+
+	optionalFiles := make(map[string]bool, len(opts.Optional))
+	for _, filename := range opts.Optional {
+		optionalFiles[filename] = true
 	}
 
 	for _, filename := range filenames {
-		envMap, err := readFile(filename)
-		if err != nil {
-			return err
+		optional := optionalFiles[filename]
+
+		if opts.SearchAncestors && opts.FS == nil {
+			if filename, err = findInAncestors(filename); err != nil {
+				return sources, read, err
+			}
 		}
 
-		for key, value := range envMap {
-			if !currentEnv[key] || overload {
-				_ = os.Setenv(key, value)
+		// the definitions in this file are merged with those from the earlier files as they're
+		// parsed, so that later statements see the definition that is used. A variable that is
+		// defined more than once in this file uses the last definition, overriding the others.
+		merged := make(map[string]Source)
+		defined := make(map[string][]Definition)
+		var mergeErr error
+		define := func(key string, definition Definition) error {
+			earlier := defined[key]
+			defined[key] = append(earlier, definition)
+
+			source, exists := sources[key]
+			// copied, as the variable may be defined again later in the same file
+			overridden := source.Overridden[:len(source.Overridden):len(source.Overridden)]
+			if exists {
+				next := definition
+				if definition, mergeErr = merge(key, source.Definition, next); mergeErr != nil {
+					return mergeErr
+				}
+
+				if definition == source.Definition {
+					overridden = append(append(overridden, earlier...), next)
+				} else {
+					overridden = append(append(overridden, source.Definition), earlier...)
+				}
+			} else {
+				overridden = append(overridden, earlier...)
+			}
+
+			source.Overridden = overridden
+			source.Definition = definition
+			merged[key] = source
+			if applies == nil || applies(key) {
+				scope[key] = definition.Value
 			}
+
+			return nil
+		}
+
+		err = readFile(opts.FS, filename, parseOpts, scope, define)
+		if mergeErr != nil {
+			return sources, read, mergeErr
+		}
+		if err != nil && (optional || opts.IgnoreMissing) && errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			read = append(read, filename)
+		}
+
+		// with opts.AllErrors the variables that were parsed successfully are kept along with the errors
+		for key, source := range merged {
+			sources[key] = source
+		}
+
+		if err != nil {
+			return sources, read, err
 		}
 	}
 
-	return nil
+	return sources, read, nil
 }
 
-func readFile(filename string) (envMap map[string]string, err error) {
-	file, err := os.Open(filename)
+// readFile reads and parses the file from fsys, or from the OS file system if fsys is nil, calling
+// define for each variable that is defined. Variables are looked up using opts.LookupEnv only, and
+// commands are passed scope, both of which define is expected to keep up to date.
+func readFile(fsys fs.FS, filename string, opts ParseOptions, scope map[string]string, define func(key string, definition Definition) error) (err error) {
+	var data []byte
+	if fsys == nil {
+		data, err = os.ReadFile(filename)
+	} else {
+		data, err = fs.ReadFile(fsys, filename)
+	}
 	if err != nil {
-		return
+		return err
 	}
-	defer file.Close()
 
-	return Parse(file)
+	p := newParser(data, opts)
+	p.filename = filename
+	p.define = define
+	p.scope = scope
+
+	return p.parse(make(map[string]string), opts.lookupEnv())
 }
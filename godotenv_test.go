@@ -2,11 +2,17 @@ package godotenv_test
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
+	"math/rand"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"testing/quick"
+	"time"
 
 	"github.com/hoshsadiq/godotenv"
 )
@@ -182,6 +188,48 @@ func TestLoadFileNotFound(t *testing.T) {
 	}
 }
 
+func TestReadOptionalFiles(t *testing.T) {
+	tests := []struct {
+		name      string
+		filenames []string
+		opts      godotenv.LoadOptions
+		notExist  bool
+		expectErr bool
+	}{
+		{name: "missing required", filenames: []string{"fixtures/plain.env", "fixtures/missing.env"}, notExist: true, expectErr: true},
+		{name: "missing optional", filenames: []string{"fixtures/plain.env", "fixtures/missing.env"}, opts: godotenv.LoadOptions{Optional: []string{"fixtures/missing.env"}}},
+		{name: "ignore missing", filenames: []string{"fixtures/plain.env", "fixtures/missing.env"}, opts: godotenv.LoadOptions{IgnoreMissing: true}},
+		{name: "unreadable optional", filenames: []string{"fixtures/plain.env", "fixtures/"}, opts: godotenv.LoadOptions{Optional: []string{"fixtures/"}}, expectErr: true},
+		{name: "invalid optional", filenames: []string{"fixtures/plain.env", "fixtures/invalid1.env"}, opts: godotenv.LoadOptions{Optional: []string{"fixtures/invalid1.env"}}, expectErr: true},
+		{name: "question mark is part of the name", filenames: []string{"fixtures/plain.env", "?fixtures/missing.env"}, notExist: true, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			envMap, err := godotenv.ReadWithOptions(tt.opts, tt.filenames...)
+			if !tt.expectErr {
+				if err != nil {
+					t.Fatalf("Error reading files: %s", err)
+				}
+				if envMap["OPTION_A"] != "1" {
+					t.Errorf("Expected the existing file to be read, got %v", envMap)
+				}
+				return
+			}
+
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if notExist := errors.Is(err, fs.ErrNotExist); notExist != tt.notExist {
+				t.Errorf("Expected errors.Is(err, fs.ErrNotExist) to be %v, got %v: %v", tt.notExist, notExist, err)
+			}
+		})
+	}
+}
+
 func TestOverloadFileNotFound(t *testing.T) {
 	t.Parallel()
 
@@ -217,6 +265,56 @@ func TestReadPlainEnv(t *testing.T) {
 	}
 }
 
+func TestReadSharesScopeBetweenFiles(t *testing.T) {
+	t.Parallel()
+
+	envMap, err := godotenv.ReadWithOptions(godotenv.LoadOptions{
+		ParseOptions: godotenv.ParseOptions{LookupEnv: noLookupEnv},
+	}, "fixtures/base.env", "fixtures/local.env")
+	if err != nil {
+		t.Fatalf("Error reading files: %s", err)
+	}
+
+	expectedValues := map[string]string{
+		"DB_HOST":      "localhost",
+		"DB_PORT":      "6543",
+		"DATABASE_URL": "postgres://localhost:5432/app",
+		"REPLICA_URL":  "postgres://localhost:6543/app",
+	}
+	if !reflect.DeepEqual(expectedValues, envMap) {
+		t.Errorf("Mismatch env vars")
+		printDiff(t, expectedValues, envMap)
+	}
+}
+
+func TestLoadSharesScopeBetweenFiles(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("DB_HOST", "db.internal")
+
+	err := godotenv.Load("fixtures/base.env", "fixtures/local.env")
+	if err != nil {
+		t.Fatalf("Error loading files: %s", err)
+	}
+
+	// DB_HOST is already set, so that takes precedence over the value in base.env, and DB_PORT
+	// from base.env takes precedence over the value in local.env, even within local.env
+	expectedValues := map[string]string{
+		"DB_HOST":      "db.internal",
+		"DB_PORT":      "5432",
+		"DATABASE_URL": "postgres://db.internal:5432/app",
+		"REPLICA_URL":  "postgres://db.internal:5432/app",
+	}
+	for k, v := range expectedValues {
+		if envValue := os.Getenv(k); envValue != v {
+			t.Errorf("Mismatch for key '%v': expected '%v' got '%v'", k, v, envValue)
+		}
+	}
+}
+
+func noLookupEnv([]byte) ([]byte, bool) {
+	return nil, false
+}
+
 func TestParse(t *testing.T) {
 	t.Parallel()
 
@@ -344,6 +442,46 @@ func TestExpanding(t *testing.T) {
 			"FOO=test\nBAR=\"foo\\${FOO} ${FOO}\"",
 			map[string]string{"FOO": "test", "BAR": "foo${FOO} test"},
 		},
+		{
+			"expands nested defaults",
+			"BAR=${FOO:-${BAZ:-default}}",
+			map[string]string{"BAR": "default"},
+		},
+		{
+			"expands chained fallbacks",
+			"REGION_URL=https://region\nURL=\"${OVERRIDE_URL:-${REGION_URL:-https://default}}\"",
+			map[string]string{"REGION_URL": "https://region", "URL": "https://region"},
+		},
+		{
+			"expands variables in alternate words",
+			"FOO=test\nBAR=${FOO:+$FOO-alt}",
+			map[string]string{"FOO": "test", "BAR": "test-alt"},
+		},
+		{
+			"does not expand single quotes in words",
+			"BAR=${FOO:-'$BAZ }'}",
+			map[string]string{"BAR": "$BAZ }"},
+		},
+		{
+			"allows braces in double quoted words",
+			`BAR="${FOO:-"a } b"}"`,
+			map[string]string{"BAR": "a } b"},
+		},
+		{
+			"allows escaped braces in words",
+			`BAR=${FOO:-a\}b}`,
+			map[string]string{"BAR": "a}b"},
+		},
+		{
+			"allows balanced braces in words",
+			`BAR=${FOO:-{a}}`,
+			map[string]string{"BAR": "{a}"},
+		},
+		{
+			"does not evaluate unused words",
+			"FOO=test\nBAR=${FOO:-${BAZ:?must be set}}",
+			map[string]string{"FOO": "test", "BAR": "test"},
+		},
 	}
 
 	t.Parallel()
@@ -365,6 +503,355 @@ func TestExpanding(t *testing.T) {
 
 }
 
+func TestStrictMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected map[string]string
+		unbound  string
+		line     int
+		column   int
+	}{
+		{name: "set variables are expanded", input: "FOO=test\nBAR=$FOO", expected: map[string]string{"FOO": "test", "BAR": "test"}},
+		{name: "environment variables are expanded", input: "BAR=${PRESET}", expected: map[string]string{"BAR": "preset"}},
+		{name: "defaults are allowed", input: "BAR=${FOO:-default}", expected: map[string]string{"BAR": "default"}},
+		{name: "alternates are allowed", input: "BAR=${FOO+alt}", expected: map[string]string{"BAR": ""}},
+		{name: "unset variable", input: "BAR=$FOO", unbound: "FOO", line: 1, column: 5},
+		{name: "unset variable in braces", input: "A=1\nBAR=\"x ${FOO}\"", unbound: "FOO", line: 2, column: 8},
+		{name: "unset variable in manipulation", input: "BAR=${FOO#prefix}", unbound: "FOO", line: 1, column: 5},
+		{name: "unset variable in length", input: "BAR=${#FOO}", unbound: "FOO", line: 1, column: 5},
+		{name: "unset positional parameter", input: "BAR=$1", unbound: "1", line: 1, column: 5},
+		{name: "special parameters are always set", input: "BAR=$@$#$0", expected: map[string]string{"BAR": "0"}},
+	}
+
+	lookupEnv := func(name []byte) ([]byte, bool) {
+		if string(name) == "PRESET" {
+			return []byte("preset"), true
+		}
+
+		return nil, false
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := godotenv.ParseWithOptions(strings.NewReader(tt.input), godotenv.ParseOptions{
+				LookupEnv: lookupEnv,
+				Strict:    true,
+			})
+
+			if tt.unbound == "" {
+				if err != nil {
+					t.Fatalf("Error: %s", err.Error())
+				}
+				if !reflect.DeepEqual(tt.expected, env) {
+					t.Errorf("Mismatch env vars")
+					printDiff(t, tt.expected, env)
+				}
+				return
+			}
+
+			var unboundErr godotenv.UnboundVariableError
+			if !errors.As(err, &unboundErr) {
+				t.Fatalf("Expected an UnboundVariableError, got %v", err)
+			}
+			if unboundErr.Name != tt.unbound || unboundErr.Line != tt.line || unboundErr.Column != tt.column {
+				t.Errorf("Expected %s unbound at %d:%d, got %s at %d:%d", tt.unbound, tt.line, tt.column, unboundErr.Name, unboundErr.Line, unboundErr.Column)
+			}
+		})
+	}
+}
+
+func TestAssignDefaultExpansion(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		env      map[string]string
+		expected map[string]string
+	}{
+		{
+			name:     "unset variable is assigned",
+			input:    "ADDR=localhost:${PORT:=8080}\nURL=http://$ADDR/$PORT",
+			expected: map[string]string{"ADDR": "localhost:8080", "PORT": "8080", "URL": "http://localhost:8080/8080"},
+		},
+		{
+			name:     "empty variable is assigned with colon",
+			input:    "ADDR=localhost:${PORT:=8080}",
+			env:      map[string]string{"PORT": ""},
+			expected: map[string]string{"ADDR": "localhost:8080", "PORT": "8080"},
+		},
+		{
+			name:     "empty variable is not assigned without colon",
+			input:    "ADDR=localhost:${PORT=8080}",
+			env:      map[string]string{"PORT": ""},
+			expected: map[string]string{"ADDR": "localhost:"},
+		},
+		{
+			name:     "set variable is not assigned",
+			input:    `ADDR="localhost:${PORT:=8080}"`,
+			env:      map[string]string{"PORT": "9000"},
+			expected: map[string]string{"ADDR": "localhost:9000"},
+		},
+		{
+			name:     "later definitions take precedence",
+			input:    "ADDR=localhost:${PORT=8080}\nPORT=9000",
+			expected: map[string]string{"ADDR": "localhost:8080", "PORT": "9000"},
+		},
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := godotenv.ParseWithLookup(strings.NewReader(tt.input), func(name []byte) ([]byte, bool) {
+				val, exists := tt.env[string(name)]
+				return []byte(val), exists
+			})
+			if err != nil {
+				t.Fatalf("Error: %s", err.Error())
+			}
+			if !reflect.DeepEqual(tt.expected, env) {
+				t.Errorf("Mismatch env vars")
+				printDiff(t, tt.expected, env)
+			}
+		})
+	}
+}
+
+func TestStringManipulationExpansion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `${#V}`, expected: "18"},
+		{input: `${#W}`, expected: "5"},
+		{input: `${V#*.}`, expected: "world.tar.gz"},
+		{input: `${V##*.}`, expected: "gz"},
+		{input: `${V%.*}`, expected: "hello.world.tar"},
+		{input: `${V%%.*}`, expected: "hello"},
+		{input: `${V#nomatch}`, expected: "hello.world.tar.gz"},
+		{input: `${URL#*://}`, expected: "example.com:8443/v1"},
+		{input: `${URL%%:*}`, expected: "https"},
+		{input: `${V/./-}`, expected: "hello-world.tar.gz"},
+		{input: `${V//./-}`, expected: "hello-world-tar-gz"},
+		{input: `${V//[aeiou]/}`, expected: "hll.wrld.tr.gz"},
+		{input: `${V/world}`, expected: "hello..tar.gz"},
+		{input: `${V/hello/$U}`, expected: "ABC.world.tar.gz"},
+		{input: `"${V/hello/a b}"`, expected: "a b.world.tar.gz"},
+		{input: `${S/\*/-}`, expected: "a-b*c"},
+		{input: `${S//"*"/-}`, expected: "a-b-c"},
+		{input: `${S//'*'/-}`, expected: "a-b-c"},
+		{input: `${V/#hello/bye}`, expected: "bye.world.tar.gz"},
+		{input: `${V/#world/bye}`, expected: "hello.world.tar.gz"},
+		{input: `${V/#*./}`, expected: "gz"},
+		{input: `${V/%gz/tgz}`, expected: "hello.world.tar.tgz"},
+		{input: `${V/%tar/zip}`, expected: "hello.world.tar.gz"},
+		{input: `${V/%.*/}`, expected: "hello"},
+		{input: `${U/#/x}`, expected: "xABC"},
+		{input: `${U/%/x}`, expected: "ABCx"},
+		{input: `${S/"#"a/-}`, expected: "a*b*c"},
+		{input: `${U//#/x}`, expected: "ABC"},
+		{input: `${V%%$P*}`, expected: "hello"},
+		{input: `${V%%"$P"*}`, expected: "hello"},
+		{input: `${V:6}`, expected: "world.tar.gz"},
+		{input: `${V:6:5}`, expected: "world"},
+		{input: `${V: -2}`, expected: "gz"},
+		{input: `${V:(-6):3}`, expected: "tar"},
+		{input: `${V:0:-3}`, expected: "hello.world.tar"},
+		{input: `${V:100}`, expected: ""},
+		{input: `${W:1:3}`, expected: "éll"},
+		{input: `${V^^}`, expected: "HELLO.WORLD.TAR.GZ"},
+		{input: `${V^}`, expected: "Hello.world.tar.gz"},
+		{input: `${U,,}`, expected: "abc"},
+		{input: `${U,}`, expected: "aBC"},
+		{input: `${W^^}`, expected: "HÉLLO"},
+		{input: `${NOTSET#x}`, expected: ""},
+	}
+
+	env := map[string]string{
+		"V":   "hello.world.tar.gz",
+		"W":   "héllo",
+		"U":   "ABC",
+		"S":   "a*b*c",
+		"P":   ".",
+		"URL": "https://example.com:8443/v1",
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			envMap, err := godotenv.ParseWithLookup(strings.NewReader("BAR="+tt.input), func(name []byte) ([]byte, bool) {
+				val, exists := env[string(name)]
+				return []byte(val), exists
+			})
+			if err != nil {
+				t.Fatalf("Error: %s", err.Error())
+			}
+			if envMap["BAR"] != tt.expected {
+				t.Errorf("Expected %s to expand to %q, got %q", tt.input, tt.expected, envMap["BAR"])
+			}
+		})
+	}
+}
+
+func TestPatternExpansionOnLongValues(t *testing.T) {
+	t.Parallel()
+
+	// about the size of a PEM certificate, which must not make matching take noticeably long
+	value := strings.Repeat("MIIDdzCCAl+gAwIBAgIEAgAAuTANBgkqhkiG9w0BAQUFADBaMQswCQYDVQQGEwJJ\n", 128)
+	tests := []string{`${V//a/x}`, `${V//*a*a*c/x}`, `${V/*a*a*z*/x}`, `${V##*a*a*c}`, `${V%*a*a*c}`, `${V/%*a*?*c*/x}`}
+
+	start := time.Now()
+	for _, input := range tests {
+		_, err := godotenv.ParseWithLookup(strings.NewReader("BAR="+input), func(name []byte) ([]byte, bool) {
+			return []byte(value), string(name) == "V"
+		})
+		if err != nil {
+			t.Fatalf("Error expanding %s: %s", input, err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the expansions to take well under 2s, took %s", elapsed)
+	}
+}
+
+func TestPositionalParameters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: `/var/log/$1.log`, expected: "/var/log/web.log"},
+		{input: `"$0 $2"`, expected: "wrapper --port"},
+		{input: `$12`, expected: "web2"},
+		{input: `${10}`, expected: "ten"},
+		{input: `${11:-none}`, expected: "none"},
+		{input: `${1:+set}`, expected: "set"},
+		{input: `"$@"`, expected: "web --port 8080 4 5 6 7 8 9 ten"},
+		{input: `${*}`, expected: "web --port 8080 4 5 6 7 8 9 ten"},
+		{input: `$#`, expected: "10"},
+		{input: `${#}`, expected: "10"},
+		{input: `${#1}`, expected: "3"},
+		{input: `$$`, expected: "1234"},
+		{input: `${1^^}`, expected: "WEB"},
+		{input: `$?$!`, expected: ""},
+		{input: `${00}`, expected: ""},
+		{input: `${01}`, expected: "web"},
+		{input: `${@:1}`, expected: "web --port 8080 4 5 6 7 8 9 ten"},
+		{input: `${@:0:2}`, expected: "wrapper web"},
+		{input: `"${*:2:2}"`, expected: "--port 8080"},
+		{input: `${@: -2}`, expected: "9 ten"},
+		{input: `${@:11}`, expected: ""},
+		{input: `${#@}`, expected: "10"},
+		{input: `${#*}`, expected: "10"},
+		{input: `${*#*e}`, expected: "b --port 8080 4 5 6 7 8 9 n"},
+		{input: `${@/t/T}`, expected: "web --porT 8080 4 5 6 7 8 9 Ten"},
+		{input: `${@^}`, expected: "Web --port 8080 4 5 6 7 8 9 Ten"},
+	}
+
+	opts := godotenv.ParseOptions{
+		Args: []string{"web", "--port", "8080", "4", "5", "6", "7", "8", "9", "ten"},
+		Arg0: "wrapper",
+		PID:  1234,
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			envMap, err := godotenv.ParseWithOptions(strings.NewReader("BAR="+tt.input), opts)
+			if err != nil {
+				t.Fatalf("Error: %s", err.Error())
+			}
+			if envMap["BAR"] != tt.expected {
+				t.Errorf("Expected %s to expand to %q, got %q", tt.input, tt.expected, envMap["BAR"])
+			}
+		})
+	}
+}
+
+func TestPositionalParametersNegativeLength(t *testing.T) {
+	t.Parallel()
+
+	_, err := godotenv.ParseWithOptions(strings.NewReader("BAR=${@:1:-1}"), godotenv.ParseOptions{Args: []string{"a", "b"}})
+
+	var parseErr godotenv.ParseError
+	if !errors.As(err, &parseErr) || parseErr.Kind != godotenv.KindBadSubstitution {
+		t.Errorf("Expected a bad substitution, got %v", err)
+	}
+}
+
+func TestRequiredExpansion(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		required string
+		message  string
+		line     int
+		column   int
+	}{
+		{name: "set variable", input: "BAR=${PRESET:?must be set}", expected: "preset"},
+		{name: "empty variable without colon", input: "BAR=${EMPTY?must be set}", expected: ""},
+		{name: "unset variable", input: "BAR=${FOO?must be set}", required: "FOO", message: "must be set", line: 1, column: 5},
+		{name: "unset variable with colon", input: "A=1\nBAR=\"${FOO:?must be set by the platform}\"", required: "FOO", message: "must be set by the platform", line: 2, column: 6},
+		{name: "empty variable with colon", input: "BAR=${EMPTY:?}", required: "EMPTY", message: "parameter null or not set", line: 1, column: 5},
+		{name: "unset variable without message", input: "BAR=${FOO?}", required: "FOO", message: "parameter not set", line: 1, column: 5},
+	}
+
+	lookupEnv := func(name []byte) ([]byte, bool) {
+		switch string(name) {
+		case "PRESET":
+			return []byte("preset"), true
+		case "EMPTY":
+			return []byte(""), true
+		}
+
+		return nil, false
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			env, err := godotenv.ParseWithLookup(strings.NewReader(tt.input), lookupEnv)
+
+			if tt.required == "" {
+				if err != nil {
+					t.Fatalf("Error: %s", err.Error())
+				}
+				if env["BAR"] != tt.expected {
+					t.Errorf("Expected: %q, Actual: %q", tt.expected, env["BAR"])
+				}
+				return
+			}
+
+			var requiredErr godotenv.RequiredVariableError
+			if !errors.As(err, &requiredErr) {
+				t.Fatalf("Expected a RequiredVariableError, got %v", err)
+			}
+			if requiredErr.Name != tt.required || requiredErr.Message != tt.message {
+				t.Errorf("Expected %s to be required with %q, got %s with %q", tt.required, tt.message, requiredErr.Name, requiredErr.Message)
+			}
+			if requiredErr.Line != tt.line || requiredErr.Column != tt.column {
+				t.Errorf("Expected error at %d:%d, got %d:%d", tt.line, tt.column, requiredErr.Line, requiredErr.Column)
+			}
+		})
+	}
+}
+
 func TestActualEnvVarsAreLeftAlone(t *testing.T) {
 	os.Clearenv()
 	os.Setenv("OPTION_A", "actualenv")
@@ -442,9 +929,9 @@ func TestParsing(t *testing.T) {
 		{rawEnvLine: "FOO='ba#r'", expectedKey: "FOO", expectedValue: "ba#r"},
 
 		// newlines and backslashes should be escaped
-		{rawEnvLine: `FOO="bar\n\ b\az"`, expectedKey: "FOO", expectedValue: "bar\n baz"},
-		{rawEnvLine: `FOO="bar\\\n\ b\az"`, expectedKey: "FOO", expectedValue: "bar\\\n baz"},
-		{rawEnvLine: `FOO="bar\\r\ b\az"`, expectedKey: "FOO", expectedValue: "bar\\r baz"},
+		{rawEnvLine: `FOO="bar\n\ b\az"`, expectedKey: "FOO", expectedValue: "bar\n b\az"},
+		{rawEnvLine: `FOO="bar\\\n\ b\az"`, expectedKey: "FOO", expectedValue: "bar\\\n b\az"},
+		{rawEnvLine: `FOO="bar\\r\ b\az"`, expectedKey: "FOO", expectedValue: "bar\\r b\az"},
 
 		{rawEnvLine: `="value"`, expectedKey: "", expectedValue: ""},
 		{rawEnvLine: `KEY="`, expectedKey: "", expectedValue: ""},
@@ -480,6 +967,14 @@ func TestParsing(t *testing.T) {
 		// Hashes are comments if it's directly followed by whitespace
 		{rawEnvLine: `FOO=asd#asd`, expectedKey: "FOO", expectedValue: "asd#asd"},
 		{rawEnvLine: `FOO=asd #asd`, expectedKey: "FOO", expectedValue: "asd"},
+
+		// comments at the start and end of the input
+		{rawEnvLine: "# comment\nFOO=bar", expectedKey: "FOO", expectedValue: "bar"},
+		{rawEnvLine: "FOO=bar\n# comment", expectedKey: "FOO", expectedValue: "bar"},
+		{rawEnvLine: "FOO=bar # comment", expectedKey: "FOO", expectedValue: "bar"},
+		{rawEnvLine: "FOO='' # comment", expectedKey: "FOO", expectedValue: ""},
+		{rawEnvLine: `FOO="" # comment`, expectedKey: "FOO", expectedValue: ""},
+		{rawEnvLine: "FOO= # comment", expectedKey: "", expectedValue: ""},
 	}
 
 	t.Parallel()
@@ -521,6 +1016,75 @@ func TestParsing(t *testing.T) {
 	}
 }
 
+func TestEscapeSequences(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		err      bool
+	}{
+		{input: `"\x41\x4a"`, expected: "AJ"},
+		{input: `"\x7"`, expected: "\x07"},
+		{input: `"\xff"`, expected: "\xff"},
+		{input: `"\xg"`, err: true},
+		{input: `"\x`, err: true},
+		{input: `"\u00e9t\u00E9"`, expected: "été"},
+		{input: `"\u2028"`, expected: "\u2028"},
+		{input: `"\u41"`, expected: "A"},
+		{input: `"\U0001F600"`, expected: "😀"},
+		{input: `"\Ud800"`, err: true},
+		{input: `"\U00110000"`, err: true},
+		{input: `"\u"`, err: true},
+		{input: `"\101\0"`, expected: "A\x00"},
+		{input: `"\0101"`, expected: "\x081"},
+		{input: `"\400"`, err: true},
+		{input: `"\v"`, expected: "\v"},
+		{input: `"\a"`, expected: "\a"},
+		{input: `\x41`, expected: "x41"},
+		{input: `'\x41'`, expected: `\x41`},
+		{input: `"${FOO:-\x41}"`, expected: "A"},
+	}
+
+	t.Parallel()
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.input, func(t *testing.T) {
+			t.Parallel()
+
+			envMap, err := godotenv.Unmarshal("FOO=" + tt.input)
+			if tt.err {
+				if err == nil {
+					t.Errorf("Expected %s to fail, got %q", tt.input, envMap["FOO"])
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Error: %s", err.Error())
+			}
+			if envMap["FOO"] != tt.expected {
+				t.Errorf("Expected %s to parse as %q, got %q", tt.input, tt.expected, envMap["FOO"])
+			}
+		})
+	}
+}
+
+func TestUnmarshalGoQuotedStrings(t *testing.T) {
+	t.Parallel()
+
+	values := []string{"\x00\x01\x1f\x7f", "\u2028\u00ad", "\U0001F600 \U000E0001", "\xff\xfe", "tab\tnew\nline", "\a\b\f\v\r", `"quoted" \back\slash`}
+	for _, value := range values {
+		envMap, err := godotenv.Unmarshal("FOO=" + strconv.Quote(value))
+		if err != nil {
+			t.Errorf("Error parsing %s: %s", strconv.Quote(value), err.Error())
+			continue
+		}
+
+		if envMap["FOO"] != value {
+			t.Errorf("Expected %s to parse as %q, got %q", strconv.Quote(value), value, envMap["FOO"])
+		}
+	}
+}
+
 func TestErrorReadDirectory(t *testing.T) {
 	t.Parallel()
 
@@ -539,6 +1103,112 @@ func TestErrorParsing(t *testing.T) {
 	if err == nil {
 		t.Errorf("Expected error, got %v: %s", envMap, err)
 	}
+
+	var parseErr godotenv.ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Expected a ParseError, got %v", err)
+	}
+	if parseErr.Filename != envFileName || parseErr.Line != 1 || parseErr.Column != 8 {
+		t.Errorf("Expected error at %s:1:8, got %s:%d:%d", envFileName, parseErr.Filename, parseErr.Line, parseErr.Column)
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+		offset int
+		caret  string
+	}{
+		{"first line", "FOO BAR=1", 1, 4, 3, "\tFOO BAR=1\n\t   ^ Right here"},
+		{"later line", "A=1\nB=2\nFOO BAR=1", 3, 4, 11, "\tFOO BAR=1\n\t   ^ Right here"},
+		{"multi-byte characters", "A=1\nB=héllo\x01", 2, 8, 12, "\tB=héllo\x01\n\t       ^ Right here"},
+		{"tabs", "A=1\n\tB=\"x\"\tFOO", 2, 7, 10, "\t\tB=\"x\"\tFOO\n\t\t     ^ Right here"},
+		{"crlf", "A=1\r\nFOO BAR=1\r\n", 2, 4, 8, "\tFOO BAR=1\n\t   ^ Right here"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := godotenv.Unmarshal(tt.input)
+
+			var parseErr godotenv.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a ParseError, got %v", err)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column || parseErr.Offset != tt.offset {
+				t.Errorf("Expected error at %d:%d (offset %d), got %d:%d (offset %d)", tt.line, tt.column, tt.offset, parseErr.Line, parseErr.Column, parseErr.Offset)
+			}
+			if !strings.HasSuffix(err.Error(), tt.caret) {
+				t.Errorf("Expected error to end with %q, got %q", tt.caret, err.Error())
+			}
+		})
+	}
+}
+
+func TestInvalidCharacterError(t *testing.T) {
+	t.Parallel()
+
+	_, err := godotenv.Unmarshal("A=1\nB=x\x01")
+
+	var invalidErr godotenv.InvalidCharacterError
+	if !errors.As(err, &invalidErr) {
+		t.Fatalf("Expected an InvalidCharacterError, got %v", err)
+	}
+	if invalidErr.Char != 0x01 || invalidErr.Kind != godotenv.KindInvalidValue || invalidErr.Line != 2 || invalidErr.Column != 4 {
+		t.Errorf("Expected 0x01 at 2:4, got 0x%0.2x at %d:%d", invalidErr.Char, invalidErr.Line, invalidErr.Column)
+	}
+}
+
+func TestAllErrors(t *testing.T) {
+	t.Parallel()
+
+	input := "A=1\n1B=2\nC=3\nD = 4\nE=${UNSET?}\nF=\"unterminated\n"
+	env, err := godotenv.ParseWithOptions(strings.NewReader(input), godotenv.ParseOptions{AllErrors: true})
+
+	var parseErrs godotenv.ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("Expected ParseErrors, got %v", err)
+	}
+
+	type position struct {
+		line, column int
+		kind         godotenv.ErrorKind
+	}
+	expected := []position{
+		{2, 1, godotenv.KindInvalidKey},
+		{4, 2, godotenv.KindInvalidKey},
+		{5, 3, godotenv.KindRequiredVariable},
+		{7, 1, godotenv.KindUnterminated},
+	}
+
+	if len(parseErrs) != len(expected) {
+		t.Fatalf("Expected %d errors, got %d: %v", len(expected), len(parseErrs), err)
+	}
+	for i, want := range expected {
+		var parseErr godotenv.ParseError
+		if !errors.As(parseErrs[i], &parseErr) {
+			t.Fatalf("Expected a ParseError, got %v", parseErrs[i])
+		}
+		if got := (position{parseErr.Line, parseErr.Column, parseErr.Kind}); got != want {
+			t.Errorf("Expected error %d to be %v at %d:%d, got %v at %d:%d", i, want.kind, want.line, want.column, got.kind, got.line, got.column)
+		}
+	}
+
+	var requiredErr godotenv.RequiredVariableError
+	if !errors.As(err, &requiredErr) || requiredErr.Name != "UNSET" {
+		t.Errorf("Expected a RequiredVariableError for UNSET, got %v", err)
+	}
+
+	expectedEnv := map[string]string{"A": "1", "C": "3"}
+	if !reflect.DeepEqual(expectedEnv, env) {
+		t.Errorf("Mismatch env vars")
+		printDiff(t, expectedEnv, env)
+	}
 }
 
 // just test some single lines to show the general idea
@@ -547,24 +1217,29 @@ func TestWrite(t *testing.T) {
 		env      string
 		expected string
 	}{
-		// values are always double-quoted
-		{env: `key="test\${hello}test"`, expected: `key="test${hello}test"`},
-		// values are always double-quoted
-		{env: `key=value`, expected: `key="value"`},
+		// values are single-quoted where possible, so nothing is expanded when read back
+		{env: `key="test\${hello}test"`, expected: `key='test${hello}test'`},
+		{env: `key=value`, expected: `key='value'`},
 		// non-nested double-quotes are seen as strings
-		{env: `key=va"lu"e`, expected: `key="value"`},
+		{env: `key=va"lu"e`, expected: `key='value'`},
 		// same with single quotes
-		{env: `key=va'lu'e`, expected: `key="value"`},
-		// nested double quoted variables are escaped
-		{env: `key='va"lu"e'`, expected: `key="va\"lu\"e"`},
-		// nested single quoted variables are left alone
+		{env: `key=va'lu'e`, expected: `key='value'`},
+		// nested double quotes are left alone in single quotes
+		{env: `key='va"lu"e'`, expected: `key='va"lu"e'`},
+		// values holding single quotes are double-quoted
 		{env: `key="va'lu'e"`, expected: `key="va'lu'e"`},
+		// with dollar signs escaped, so they're not expanded
+		{env: `key="it's \$HOME"`, expected: `key="it's \$HOME"`},
 		// newlines, backslashes, and some other special chars are escaped
 		{env: `foo="\n\r\\r!"`, expected: `foo="\n\r\\r!"`},
+		// a trailing backslash would escape the closing single quote
+		{env: `foo="bar\\"`, expected: `foo="bar\\"`},
 		// lines should be sorted
-		{env: "foo=bar\nbaz=buzz", expected: "baz=\"buzz\"\nfoo=\"bar\""},
-		// integers should not be quoted
-		{env: `key="10"`, expected: `key=10`},
+		{env: "foo=bar\nbaz=buzz", expected: "baz='buzz'\nfoo='bar'"},
+		// numbers are left as is
+		{env: `key="10"`, expected: `key='10'`},
+		{env: `key="007"`, expected: `key='007'`},
+		{env: `key="+5"`, expected: `key='+5'`},
 	}
 
 	t.Parallel()
@@ -582,6 +1257,83 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+// tricky holds the characters that are most likely to be interpreted by the parser.
+const tricky = "$${}()'\"\\\n\r\t #=:-?+%/^,!@*`ab1_ \x00\x7féす"
+
+func TestMarshalRoundTripProperty(t *testing.T) {
+	t.Parallel()
+
+	// any variable that is expanded by mistake shows up in the value
+	lookupEnv := func([]byte) ([]byte, bool) {
+		return []byte("EXPANDED"), true
+	}
+
+	roundTrips := func(values []string) bool {
+		envMap := make(map[string]string, len(values))
+		for i, v := range values {
+			envMap["KEY_"+strconv.Itoa(i)] = v
+		}
+
+		marshalled, err := godotenv.Marshal(envMap)
+		if err != nil {
+			t.Logf("Error marshalling %q: %s", values, err)
+			return false
+		}
+
+		actual, err := godotenv.ParseWithOptions(strings.NewReader(marshalled), godotenv.ParseOptions{LookupEnv: lookupEnv})
+		if err != nil {
+			t.Logf("Error unmarshalling %q: %s", marshalled, err)
+			return false
+		}
+
+		if !reflect.DeepEqual(envMap, actual) {
+			t.Logf("Mismatch for %q", marshalled)
+			printDiff(t, envMap, actual)
+			return false
+		}
+
+		return true
+	}
+
+	generators := map[string]func(r *rand.Rand) string{
+		"unicode": func(r *rand.Rand) string {
+			v, _ := quick.Value(reflect.TypeOf(""), r)
+			return v.String()
+		},
+		"bytes": func(r *rand.Rand) string {
+			b := make([]byte, r.Intn(32))
+			r.Read(b)
+			return string(b)
+		},
+		"tricky": func(r *rand.Rand) string {
+			runes := []rune(tricky)
+			v := make([]rune, r.Intn(32))
+			for i := range v {
+				v[i] = runes[r.Intn(len(runes))]
+			}
+			return string(v)
+		},
+	}
+
+	for name, generate := range generators {
+		generate := generate
+		config := &quick.Config{
+			MaxCount: 2000,
+			Values: func(args []reflect.Value, r *rand.Rand) {
+				values := make([]string, 1+r.Intn(4))
+				for i := range values {
+					values[i] = generate(r)
+				}
+				args[0] = reflect.ValueOf(values)
+			},
+		}
+
+		if err := quick.Check(roundTrips, config); err != nil {
+			t.Errorf("%s: %s", name, err)
+		}
+	}
+}
+
 func TestRoundTrip(t *testing.T) {
 	t.Parallel()
 
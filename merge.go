@@ -0,0 +1,80 @@
+package godotenv
+
+import "fmt"
+
+// Definition is the value of a variable along with where it was defined.
+type Definition struct {
+	Value    string
+	Filename string
+	// Line is the line the variable is defined on, and Raw is the statement as it is written in
+	// the file. For variables assigned by an expansion, such as ${FOO:=default}, Raw is the
+	// statement holding the expansion.
+	Line int
+	Raw  string
+}
+
+func (d Definition) String() string {
+	return fmt.Sprintf("%s:%d", d.Filename, d.Line)
+}
+
+// MergeStrategy decides which definition is used when a variable is defined in more than one file.
+// It is called with the definition that is currently used and the definition from the file that
+// is being read. Returning an error stops reading any further files.
+//
+// FirstWins, LastWins and ErrorOnConflict can be used as is, or a custom function can be used to
+// decide on a per-key basis.
+type MergeStrategy func(key string, current, next Definition) (Definition, error)
+
+// FirstWins keeps the definition from the first file that defines a variable. This is the default for Load.
+func FirstWins(_ string, current, _ Definition) (Definition, error) {
+	return current, nil
+}
+
+// LastWins uses the definition from the last file that defines a variable. This is the default
+// for Read and Overload.
+func LastWins(_ string, _, next Definition) (Definition, error) {
+	return next, nil
+}
+
+// ErrorOnConflict returns a ConflictError when a variable is defined in more than one file with
+// different values.
+func ErrorOnConflict(key string, current, next Definition) (Definition, error) {
+	if current.Value != next.Value {
+		return current, ConflictError{Key: key, First: current, Second: next}
+	}
+
+	return current, nil
+}
+
+// Source describes where the value of a variable comes from.
+type Source struct {
+	// Definition is the definition that is used. Only Value is set when FromEnv is true.
+	Definition
+	// FromEnv is true when the variable is already set in the environment of the process, which
+	// takes precedence over the definitions in the files.
+	FromEnv bool
+	// Overridden holds the definitions that were overridden by Definition, in the order they were read.
+	// When FromEnv is true, the last one is the definition that would have been used otherwise.
+	Overridden []Definition
+}
+
+// String returns the value along with where it comes from, e.g. "8080 (.env:3)" or
+// "8080 (environment)".
+func (s Source) String() string {
+	if s.FromEnv {
+		return fmt.Sprintf("%s (environment)", s.Value)
+	}
+
+	return fmt.Sprintf("%s (%s)", s.Value, s.Definition)
+}
+
+// ConflictError is returned by ErrorOnConflict when a variable is defined in more than one file.
+type ConflictError struct {
+	Key    string
+	First  Definition
+	Second Definition
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("godotenv: %s is defined with different values in %s and %s", e.Key, e.First, e.Second)
+}
@@ -0,0 +1,354 @@
+package godotenv_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestMergeStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy godotenv.MergeStrategy
+		expected map[string]string
+	}{
+		{
+			name:     "default",
+			expected: map[string]string{"DB_HOST": "localhost", "DB_PORT": "6543", "REPLICA_URL": "postgres://localhost:6543/app"},
+		},
+		{
+			name:     "first wins",
+			strategy: godotenv.FirstWins,
+			expected: map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432", "REPLICA_URL": "postgres://localhost:5432/app"},
+		},
+		{
+			name:     "last wins",
+			strategy: godotenv.LastWins,
+			expected: map[string]string{"DB_HOST": "localhost", "DB_PORT": "6543", "REPLICA_URL": "postgres://localhost:6543/app"},
+		},
+		{
+			name: "per key",
+			strategy: func(key string, current, next godotenv.Definition) (godotenv.Definition, error) {
+				if key == "DB_PORT" {
+					next.Value = current.Value + "," + next.Value
+				}
+				return next, nil
+			},
+			expected: map[string]string{"DB_HOST": "localhost", "DB_PORT": "5432,6543", "REPLICA_URL": "postgres://localhost:5432,6543/app"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			envMap, err := godotenv.ReadWithOptions(godotenv.LoadOptions{
+				ParseOptions:  godotenv.ParseOptions{LookupEnv: noLookupEnv},
+				MergeStrategy: tt.strategy,
+			}, "fixtures/base.env", "fixtures/local.env")
+			if err != nil {
+				t.Fatalf("Error reading files: %s", err)
+			}
+
+			actual := map[string]string{"DB_HOST": envMap["DB_HOST"], "DB_PORT": envMap["DB_PORT"], "REPLICA_URL": envMap["REPLICA_URL"]}
+			if !reflect.DeepEqual(tt.expected, actual) {
+				t.Errorf("Mismatch env vars")
+				printDiff(t, tt.expected, actual)
+			}
+		})
+	}
+}
+
+func TestMergeStrategyWithinFile(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"first.env":  {Data: []byte("A=first\n")},
+		"second.env": {Data: []byte("A=second\nA=redefined\nB=$A\nC=${C:=assigned}\nD=$C\n")},
+	}
+
+	tests := []struct {
+		name     string
+		strategy godotenv.MergeStrategy
+		expected map[string]string
+	}{
+		{
+			name:     "first wins",
+			strategy: godotenv.FirstWins,
+			expected: map[string]string{"A": "first", "B": "first", "C": "assigned", "D": "assigned"},
+		},
+		{
+			name:     "last wins",
+			strategy: godotenv.LastWins,
+			expected: map[string]string{"A": "redefined", "B": "redefined", "C": "assigned", "D": "assigned"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			envMap, err := godotenv.ReadWithOptions(godotenv.LoadOptions{
+				ParseOptions:  godotenv.ParseOptions{LookupEnv: noLookupEnv},
+				FS:            fsys,
+				MergeStrategy: tt.strategy,
+			}, "first.env", "second.env")
+			if err != nil {
+				t.Fatalf("Error reading files: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, envMap) {
+				t.Errorf("Mismatch env vars")
+				printDiff(t, tt.expected, envMap)
+			}
+		})
+	}
+}
+
+func TestMergeStrategyErrorOnConflict(t *testing.T) {
+	t.Parallel()
+
+	_, err := godotenv.ReadWithOptions(godotenv.LoadOptions{
+		MergeStrategy: godotenv.ErrorOnConflict,
+	}, "fixtures/base.env", "fixtures/local.env")
+
+	var conflictErr godotenv.ConflictError
+	if !errors.As(err, &conflictErr) {
+		t.Fatalf("Expected a ConflictError, got %v", err)
+	}
+
+	expected := godotenv.ConflictError{
+		Key:    "DB_PORT",
+		First:  godotenv.Definition{Value: "5432", Filename: "fixtures/base.env", Line: 2, Raw: "DB_PORT=5432"},
+		Second: godotenv.Definition{Value: "6543", Filename: "fixtures/local.env", Line: 2, Raw: "DB_PORT=6543"},
+	}
+	if conflictErr != expected {
+		t.Errorf("Expected %+v, got %+v", expected, conflictErr)
+	}
+
+	expectedMsg := "godotenv: DB_PORT is defined with different values in fixtures/base.env:2 and fixtures/local.env:2"
+	if err.Error() != expectedMsg {
+		t.Errorf("Expected %q, got %q", expectedMsg, err.Error())
+	}
+
+	if _, err := godotenv.ReadWithOptions(godotenv.LoadOptions{
+		MergeStrategy: godotenv.ErrorOnConflict,
+	}, "fixtures/base.env", "fixtures/base.env"); err != nil {
+		t.Errorf("Expected identical definitions not to conflict, got %v", err)
+	}
+}
+
+func TestLoadFirstFileWins(t *testing.T) {
+	os.Clearenv()
+
+	if err := godotenv.Load("fixtures/base.env", "fixtures/local.env"); err != nil {
+		t.Fatalf("Error loading files: %s", err)
+	}
+	if port := os.Getenv("DB_PORT"); port != "5432" {
+		t.Errorf("Expected DB_PORT from the first file to win, got %q", port)
+	}
+	if url := os.Getenv("REPLICA_URL"); url != "postgres://localhost:5432/app" {
+		t.Errorf("Expected REPLICA_URL to use DB_PORT from the first file, got %q", url)
+	}
+
+	if err := godotenv.Overload("fixtures/base.env", "fixtures/local.env"); err != nil {
+		t.Fatalf("Error loading files: %s", err)
+	}
+	if port := os.Getenv("DB_PORT"); port != "6543" {
+		t.Errorf("Expected DB_PORT from the last file to win, got %q", port)
+	}
+	if url := os.Getenv("REPLICA_URL"); url != "postgres://localhost:6543/app" {
+		t.Errorf("Expected REPLICA_URL to use DB_PORT from the last file, got %q", url)
+	}
+}
+
+func TestLoadConflictLeavesEnvUntouched(t *testing.T) {
+	os.Clearenv()
+
+	err := godotenv.LoadWithOptions(godotenv.LoadOptions{
+		MergeStrategy: godotenv.ErrorOnConflict,
+	}, "fixtures/base.env", "fixtures/local.env")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	if _, exists := os.LookupEnv("DB_HOST"); exists {
+		t.Errorf("Expected nothing to be loaded on error")
+	}
+}
+
+func TestReadWithSources(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("DB_HOST", "db.internal")
+
+	sources, err := godotenv.ReadWithSources(godotenv.LoadOptions{}, "fixtures/base.env", "fixtures/local.env")
+	if err != nil {
+		t.Fatalf("Error reading files: %s", err)
+	}
+
+	base := func(line int, raw string) godotenv.Definition {
+		value := raw[strings.IndexByte(raw, '=')+1:]
+		return godotenv.Definition{Value: value, Filename: "fixtures/base.env", Line: line, Raw: raw}
+	}
+	local := func(line int, raw, value string) godotenv.Definition {
+		return godotenv.Definition{Value: value, Filename: "fixtures/local.env", Line: line, Raw: raw}
+	}
+
+	expected := map[string]godotenv.Source{
+		"DB_HOST": {
+			Definition: godotenv.Definition{Value: "db.internal"},
+			FromEnv:    true,
+			Overridden: []godotenv.Definition{base(1, "DB_HOST=localhost")},
+		},
+		"DB_PORT": {
+			Definition: base(2, "DB_PORT=5432"),
+			Overridden: []godotenv.Definition{local(2, "DB_PORT=6543", "6543")},
+		},
+		"DATABASE_URL": {
+			Definition: local(1, "DATABASE_URL=postgres://${DB_HOST}:${DB_PORT}/app", "postgres://db.internal:5432/app"),
+		},
+		"REPLICA_URL": {
+			Definition: local(3, "REPLICA_URL=postgres://${DB_HOST}:${DB_PORT}/app", "postgres://db.internal:5432/app"),
+		},
+	}
+
+	if !reflect.DeepEqual(expected, sources) {
+		t.Errorf("Expected %+v, got %+v", expected, sources)
+	}
+
+	if _, exists := os.LookupEnv("DB_PORT"); exists {
+		t.Errorf("Expected the environment to be left alone")
+	}
+}
+
+func TestReadWithSourcesWithinFile(t *testing.T) {
+	t.Parallel()
+
+	fsys := fstest.MapFS{
+		"first.env":  {Data: []byte("WITHIN_A=first\nWITHIN_B=first\n")},
+		"second.env": {Data: []byte("WITHIN_A=second\nWITHIN_A=redefined\nWITHIN_B=${WITHIN_C:=assigned}\nWITHIN_D=\"${WITHIN_E:=multi}\nline\"\n")},
+	}
+
+	first := func(line int, raw string) godotenv.Definition {
+		value := raw[strings.IndexByte(raw, '=')+1:]
+		return godotenv.Definition{Value: value, Filename: "first.env", Line: line, Raw: raw}
+	}
+	second := func(line int, raw, value string) godotenv.Definition {
+		return godotenv.Definition{Value: value, Filename: "second.env", Line: line, Raw: raw}
+	}
+
+	assigned := second(3, "WITHIN_B=${WITHIN_C:=assigned}", "assigned")
+	multiLine := "WITHIN_D=\"${WITHIN_E:=multi}\nline\""
+	unchanged := map[string]godotenv.Source{
+		"WITHIN_C": {Definition: assigned},
+		"WITHIN_D": {Definition: second(4, multiLine, "multi\nline")},
+		"WITHIN_E": {Definition: second(4, multiLine, "multi")},
+	}
+
+	tests := []struct {
+		name     string
+		strategy godotenv.MergeStrategy
+		expected map[string]godotenv.Source
+	}{
+		{
+			name:     "first wins",
+			strategy: godotenv.FirstWins,
+			expected: map[string]godotenv.Source{
+				"WITHIN_A": {
+					Definition: first(1, "WITHIN_A=first"),
+					Overridden: []godotenv.Definition{
+						second(1, "WITHIN_A=second", "second"),
+						second(2, "WITHIN_A=redefined", "redefined"),
+					},
+				},
+				"WITHIN_B": {
+					Definition: first(2, "WITHIN_B=first"),
+					Overridden: []godotenv.Definition{assigned},
+				},
+			},
+		},
+		{
+			name:     "last wins",
+			strategy: godotenv.LastWins,
+			expected: map[string]godotenv.Source{
+				"WITHIN_A": {
+					Definition: second(2, "WITHIN_A=redefined", "redefined"),
+					Overridden: []godotenv.Definition{
+						first(1, "WITHIN_A=first"),
+						second(1, "WITHIN_A=second", "second"),
+					},
+				},
+				"WITHIN_B": {
+					Definition: assigned,
+					Overridden: []godotenv.Definition{first(2, "WITHIN_B=first")},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			for key, source := range unchanged {
+				tt.expected[key] = source
+			}
+
+			sources, err := godotenv.ReadWithSources(godotenv.LoadOptions{
+				ParseOptions:  godotenv.ParseOptions{LookupEnv: noLookupEnv},
+				FS:            fsys,
+				MergeStrategy: tt.strategy,
+			}, "first.env", "second.env")
+			if err != nil {
+				t.Fatalf("Error reading files: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, sources) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, sources)
+			}
+		})
+	}
+}
+
+func TestSourceString(t *testing.T) {
+	t.Parallel()
+
+	definition := godotenv.Definition{Value: "8080", Filename: ".env", Line: 3, Raw: "PORT=8080"}
+
+	tests := []struct {
+		name     string
+		source   godotenv.Source
+		expected string
+	}{
+		{
+			name:     "file",
+			source:   godotenv.Source{Definition: definition},
+			expected: "8080 (.env:3)",
+		},
+		{
+			name:     "environment",
+			source:   godotenv.Source{Definition: godotenv.Definition{Value: "9090"}, FromEnv: true, Overridden: []godotenv.Definition{definition}},
+			expected: "9090 (environment)",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := fmt.Sprintf("%v", tt.source); actual != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package godotenv
+
+import "io/fs"
+
+// ParseOptions configures how env files are parsed.
+type ParseOptions struct {
+	// LookupEnv is used to look up variables that are not defined earlier in the file.
+	// It defaults to LookupEnv, which looks at the environment of the current process.
+	LookupEnv func(name []byte) (value []byte, exists bool)
+
+	// Strict makes references to unset variables, such as $FOO or ${FOO}, return an
+	// UnboundVariableError rather than silently expanding to an empty string.
+	// Expansions that provide a default, such as ${FOO:-default}, are still allowed.
+	Strict bool
+
+	// AllErrors makes the parser recover from an error by skipping to the next line, rather than
+	// stopping at the first error. All errors found are returned as ParseErrors, along with the
+	// variables that were parsed successfully.
+	AllErrors bool
+
+	// CommandRunner enables command substitution, such as $(git rev-parse HEAD), and is used to run
+	// the commands. Command substitution is disabled when it's nil, which is the default.
+	CommandRunner CommandRunner
+
+	// Args holds the positional parameters $1, $2, etc. These are also used to expand $@ and $*,
+	// which expand to all the arguments separated by a space, and $#, the number of arguments.
+	Args []string
+	// Arg0 is the value of $0, which is usually the name of the command.
+	Arg0 string
+	// PID is the value of $$. $$ expands to an empty string if it's zero.
+	PID int
+}
+
+func (o ParseOptions) lookupEnv() lookupEnvFunc {
+	if o.LookupEnv == nil {
+		return LookupEnv
+	}
+
+	return o.LookupEnv
+}
+
+// LoadOptions configures how env files are loaded into the environment.
+type LoadOptions struct {
+	ParseOptions
+
+	// Overload makes variables in the env files override variables that already exist
+	// in the environment, in the same way as Overload.
+	Overload bool
+
+	// FS is the file system the files are read from, e.g. an embed.FS. The files are read from
+	// the OS file system if it's nil. Paths are as expected by fs.FS, i.e. slash-separated and
+	// without a leading slash.
+	FS fs.FS
+
+	// SearchAncestors looks for the files in the parent directories of the working directory when they
+	// don't exist in the working directory itself. The search stops at the first directory that holds
+	// a go.mod file or .git directory, or at the root, and the nearest file found is used. It is
+	// ignored when FS is set.
+	SearchAncestors bool
+
+	// Snapshot, when set, records the values of the variables before they are loaded, so that
+	// they can be put back with Snapshot.Restore.
+	Snapshot *Snapshot
+
+	// Optional lists the files that are skipped if they don't exist, rather than returning an error.
+	// Any other problem reading them, such as a permission or parse error, is still returned.
+	Optional []string
+
+	// IgnoreMissing skips files that don't exist, as if they were all listed in Optional.
+	IgnoreMissing bool
+
+	// MergeStrategy decides which value is used for variables that are defined in more than one file.
+	// It defaults to FirstWins for Load, and LastWins for Read and Overload.
+	MergeStrategy MergeStrategy
+}
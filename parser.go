@@ -2,6 +2,7 @@ package godotenv
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"unicode"
 )
@@ -28,26 +29,146 @@ const (
 type lookupEnvFunc func(name []byte) (value []byte, exists bool)
 
 type parser struct {
-	data       []byte
+	filename string
+	data     []byte
+	// firstLine is the line number of the first line in data, and lineNumber is the current line number.
+	firstLine  int
 	lineNumber int
+	// firstOffset is the offset of data in the input, which is added to the offsets in errors.
+	firstOffset int
+	// stmtStart is the offset of the statement that is being parsed.
+	stmtStart int
+	opts      ParseOptions
+
+	// env holds the variables parsed so far, and receives the variables assigned by ${VAR:=default}.
+	env map[string]string
+	// lookupEnv is used to look up the variables referenced in values.
+	lookupEnv lookupEnvFunc
+
+	// define, when set, is called with where each variable is defined. Any error it returns stops
+	// parsing and is returned as is.
+	define func(key string, definition Definition) error
+	// scope, when set, holds all the variables that can be referenced, including those defined
+	// before data, and is passed to commands instead of env. It's kept up to date through define.
+	scope map[string]string
+
+	// doc, when set, receives every statement parsed along with its raw bytes.
+	doc *Document
 }
 
-func newParser(d []byte) *parser {
+func newParser(d []byte, opts ParseOptions) *parser {
 	return &parser{
 		data:       d,
+		firstLine:  1,
 		lineNumber: 1,
+		opts:       opts,
 	}
 }
 
-func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error) {
+// parseWithLookup parses the data, expanding variables using previously parsed items
+// before falling back to lookupEnv.
+func (p *parser) parseWithLookup(lookupEnv lookupEnvFunc) (envMap map[string]string, err error) {
+	envMap = make(map[string]string)
+	err = p.parse(envMap, lookupEnvMap(envMap, lookupEnv))
+
+	return envMap, err
+}
+
+func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) error {
+	p.env = m
+	p.lookupEnv = lookupEnv
+
+	if !p.opts.AllErrors {
+		return p.parseFrom(0)
+	}
+
+	var errs ParseErrors
+	for start := 0; ; {
+		err := p.parseFrom(start)
+		if err == nil {
+			break
+		}
+		errs = append(errs, err)
+
+		// recover by skipping to the line following the error
+		var parseErr ParseError
+		if !errors.As(err, &parseErr) {
+			break
+		}
+
+		offset := parseErr.Offset - p.firstOffset
+		n := bytes.IndexByte(p.data[offset:], '\n')
+		if n == -1 {
+			break
+		}
+
+		start = offset + n + 1
+		p.lineNumber = p.firstLine + bytes.Count(p.data[:start], []byte("\n"))
+	}
+
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// parseFrom parses the statements starting at offset start, stopping at the first error.
+func (p *parser) parseFrom(start int) (err error) {
+	m := p.env
+
 	key := make([]byte, 0, len(p.data))
 	value := make([]byte, 0, len(p.data))
 
 	state := stateKey
+	p.stmtStart = start
+
+	var (
+		j int
+
+		// the below are only used to build up p.doc
+		stmtLine     = p.lineNumber
+		valueStart   int
+		commentStart = -1
+		exported     bool
+		quote        QuoteStyle
+	)
+
+	commitEntry := func(end int) error {
+		m[string(key)] = string(value)
+		if p.define != nil {
+			err := p.define(string(key), Definition{
+				Value:    string(value),
+				Filename: p.filename,
+				Line:     stmtLine,
+				Raw:      string(bytes.TrimRight(p.data[p.stmtStart:end], "\r\n")),
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		if p.doc != nil {
+			p.doc.appendEntry(p.data[p.stmtStart:end], &Entry{
+				Key:      string(key),
+				Value:    string(value),
+				Exported: exported,
+				Quote:    quote,
+				Line:     stmtLine,
+			}, valueStart, commentStart)
+		}
 
-	var j int
+		key = key[:0]
+		value = value[:0]
+		p.stmtStart, stmtLine = end, p.lineNumber
+		commentStart = -1
+		exported = false
+		quote = QuoteNone
+
+		return nil
+	}
 
-	for j = 0; j < len(p.data); j++ {
+	for j = start; j < len(p.data); j++ {
 		c := p.data[j]
 
 		switch state {
@@ -55,24 +176,34 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 			switch {
 			case c == '=':
 				if len(key) == 0 {
-					return p.newParserError(j, "empty key")
+					return p.newParserError(j, KindInvalidKey, "empty key")
 				}
 
+				valueStart = j + 1 - p.stmtStart
 				state = stateValue
 			case c == '#':
-				if unicode.IsSpace(rune(p.data[j-1])) {
-					j += bytes.IndexByte(p.data[j+1:], '\n')
+				if len(key) == 0 {
+					j = skipLine(p.data, j)
 					continue
 				}
 
-				return p.newParserError(j, "not a valid identifier")
+				return p.newParserError(j, KindInvalidKey, "not a valid identifier")
 			case c == ' ', c == '\t', c == '\r', c == '\n':
 				if bytes.Equal(key, []byte(exportPrefix)) {
 					key = key[:0]
+					exported = true
 				}
 
 				if c == '\n' {
 					p.lineNumber++
+
+					if len(key) == 0 {
+						if p.doc != nil {
+							p.doc.appendRaw(p.data[p.stmtStart : j+1])
+						}
+						p.stmtStart, stmtLine = j+1, p.lineNumber
+						exported = false
+					}
 				}
 
 				// ignore empty space
@@ -80,10 +211,10 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 					continue
 				}
 
-				return p.newParserError(j, "unexpected whitespace in key")
+				return p.newParserError(j, KindInvalidKey, "unexpected whitespace in key")
 			case unicode.IsNumber(rune(c)):
 				if len(key) == 0 {
-					return p.newParserError(j, "invalid character in key name")
+					return p.newParserError(j, KindInvalidKey, "invalid character in key name")
 				}
 				fallthrough
 			case c == '_':
@@ -91,13 +222,13 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 			case unicode.IsLetter(rune(c)):
 				key = append(key, c)
 			default:
-				return p.newParserError(j, "invalid character in key name")
+				return p.newParserError(j, KindInvalidKey, "invalid character in key name")
 			}
 		case stateValue:
 			switch c {
 			case '\r':
 				// ignore `\r` in an `\r\n`, but not in only `\r`
-				if len(p.data) >= j+1 && p.data[j+1] == '\n' {
+				if len(p.data) > j+1 && p.data[j+1] == '\n' {
 					continue
 				}
 
@@ -105,33 +236,41 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 			case '\n':
 				p.lineNumber++
 
-				m[string(key)] = string(value)
-				key = key[:0]
-				value = value[:0]
+				if err = commitEntry(j + 1); err != nil {
+					return err
+				}
 				state = stateKey
 			case '\\':
 				state = stateEscapeNone
 			case '\'':
+				if quote == QuoteNone {
+					quote = QuoteSingle
+				}
 				state = stateQuoteSingle
 			case '"':
+				if quote == QuoteNone {
+					quote = QuoteDouble
+				}
 				state = stateQuoteDouble
 			case '#':
 				if unicode.IsSpace(rune(p.data[j-1])) {
-					j += bytes.IndexByte(append(p.data[j+1:], '\n'), '\n')
+					commentStart = j - p.stmtStart
+					j = skipLine(p.data, j)
 					continue
 				}
 
 				value = append(value, c)
 			case '$':
-				res, w, err := p.resolveParameter(j, p.data[j+1:], lookupEnv)
+				res, w, err := p.resolveParameter(j, false, true)
 				if err != nil {
 					return err
 				}
 				value = append(value, res...)
 				j += w
 			case ' ':
-				if len(value) == 0 {
-					return p.newParserError(j, "unexpected space in value")
+				// a space after an empty quoted value, e.g. before a comment, is fine
+				if len(value) == 0 && quote == QuoteNone {
+					return p.newParserError(j, KindInvalidValue, "unexpected space in value")
 				}
 			default:
 				if c < 32 {
@@ -146,7 +285,7 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 		case stateQuoteDouble:
 			switch c {
 			case '$':
-				res, w, err := p.resolveParameter(j, p.data[j+1:], lookupEnv)
+				res, w, err := p.resolveParameter(j, true, true)
 				if err != nil {
 					return err
 				}
@@ -163,24 +302,13 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 				value = append(value, c)
 			}
 		case stateEscapeDouble:
-			// todo how can we combine some of these cases?
-			switch c {
-			case 'b':
-				value = append(value, '\b')
-			case 'f':
-				value = append(value, '\f')
-			case 'r':
-				value = append(value, '\r')
-			case 'n':
-				value = append(value, '\n')
-			case 't':
-				value = append(value, '\t')
-			case 'u':
-				panic("todo parse: \\u")
-			default:
-				value = append(value, c)
+			var w int
+			value, w, err = p.unescapeDouble(value, j)
+			if err != nil {
+				return err
 			}
 
+			j += w
 			state = stateQuoteDouble
 		case stateQuoteSingle:
 			switch c {
@@ -203,23 +331,27 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 	}
 
 	if state == stateValue {
-		m[string(key)] = string(value)
-		key = key[:0]
-		// value = value[:0]
+		if err = commitEntry(len(p.data)); err != nil {
+			return err
+		}
+	}
+
+	if p.doc != nil && state == stateKey && len(key) == 0 && p.stmtStart < len(p.data) {
+		p.doc.appendRaw(p.data[p.stmtStart:])
 	}
 
 	switch state {
 	case stateValue:
 	case stateKey:
 		if len(key) != 0 {
-			return p.newParserError(j, "missing value operator")
+			return p.newParserError(j, KindSyntax, "missing value operator")
 		}
 	case stateQuoteDouble:
-		return p.newParserError(j, "unmatched double quote")
+		return p.newParserError(j, KindUnterminated, "unmatched double quote")
 	case stateQuoteSingle:
-		return p.newParserError(j, "unmatched single quote")
+		return p.newParserError(j, KindUnterminated, "unmatched single quote")
 	case stateEscapeNone, stateEscapeDouble, stateEscapeSingle: // todo this can be resolved by dealing with the whole input instead of line by line
-		return p.newParserError(j, "incomplete escape sequence")
+		return p.newParserError(j, KindUnterminated, "incomplete escape sequence")
 	default:
 		panic(fmt.Errorf("state is invalid: %v. THIS IS A BUG", state))
 	}
@@ -227,117 +359,32 @@ func (p *parser) parse(m map[string]string, lookupEnv lookupEnvFunc) (err error)
 	return nil
 }
 
-// isShellSpecialVar reports whether the character identifies a special
-// shell variable such as $*.
-func isShellSpecialVar(c uint8) bool {
-	switch c {
-	case '*', '#', '$', '@', '!', '?', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-		return true
-	}
-	return false
-}
-
-// isNum reports whether the byte is an ASCII number.
-func isNum(c uint8) bool {
-	return '0' <= c && c <= '9'
-}
-
-// isAlpha reports whether the byte is an ASCII letter.
-func isAlpha(c uint8) bool {
-	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
-}
-
-// isAlphaNum reports whether the byte is an ASCII letter, number, or underscore
-func isAlphaNum(c uint8) bool {
-	return isNum(c) || isAlpha(c) || c == '_'
-}
-
-// resolveParameter resolves the parameter that begins the string and the number of bytes
-// consumed to extract the name of it. If the name is enclosed in {}, it's part of a ${}
-// expansion and this will be expanded based on a subset of POSIX specification. Namely:
-// ${VAR}				No parameter expansion
-// ${VAR:-STRING}		If VAR is empty or unset, use STRING as its value.
-// ${VAR-STRING}		If VAR is unset, use STRING as its value.
-// ${VAR:+STRING}		If VAR is not empty, use STRING as its value.
-// ${VAR+STRING}		If VAR is set, use STRING as its value.
-// https://steinbaugh.com/posts/posix.html#default-value
-// todo we should combine expandParameter with this function to avoid looping over the parameter twice.
-func (p *parser) resolveParameter(characterStart int, s []byte, lookupEnv lookupEnvFunc) (name []byte, skip int, err error) {
-	switch {
-	case s[0] == '{':
-		// Scan to closing brace
-		i := bytes.IndexByte(s, '}')
-		if i != -1 {
-			if i == 1 {
-				return nil, 2, nil // bad syntax; eat "${}"
-			}
-
-			val, err := p.expandParameter(characterStart+1, s[1:i], lookupEnv)
-			return val, i + 1, err
+// statementRaw returns the statement that is being parsed as it is written, up to the line on which
+// it ends.
+func (p *parser) statementRaw() string {
+	s := statementScanner{commands: p.opts.CommandRunner != nil}
+	end := p.stmtStart
+	for end < len(p.data) {
+		line := p.data[end:]
+		if n := bytes.IndexByte(line, '\n'); n != -1 {
+			line = line[:n+1]
 		}
 
-		return nil, 0, p.newParserError(characterStart+1, "unexpected EOF while looking for matching '}'")
-	case isShellSpecialVar(s[0]):
-		// todo how can we expand these things?
-		// one idea might be to have a special option that allows
-		// one to pass in the relevant arguments and expansion possibilities
-		return []byte(""), 1, nil
-	default:
-		// Scan alphanumerics.
-		var i int
-		for i = 0; i < len(s) && isAlphaNum(s[i]); i++ {
+		end += len(line)
+		if s.scan(line) {
+			break
 		}
-		parameter, err := p.expandParameter(characterStart, s[:i], lookupEnv)
-		return parameter, i, err
-	}
-}
-
-// todo needs better error messages.
-func (p *parser) expandParameter(characterStart int, s []byte, lookupEnv lookupEnvFunc) (value []byte, err error) {
-	var envSet bool
-
-	if len(s) > 0 && isNum(s[0]) {
-		return nil, p.newParserError(characterStart, "invalid identifier")
-	}
-
-	var i int
-	for i = 1; i < len(s) && isAlphaNum(s[i]); i++ {
-	}
-
-	value, envSet = lookupEnv(s[:i])
-	if i >= len(s) {
-		// todo error when not set
-		// return nil, p.newUnboundVariable(characterStart, s[:i])
-		return
 	}
 
-	switch s[i] {
-	case ':':
-		if i == len(s) {
-			return nil, p.newParserError(characterStart+i, "bad substitution: no modifier")
-		}
+	return string(bytes.TrimRight(p.data[p.stmtStart:end], "\r\n"))
+}
 
-		switch s[i+1] {
-		case '-':
-			if !envSet || len(value) == 0 {
-				value = s[i+2:]
-			}
-		case '+':
-			if len(value) > 0 {
-				value = s[i+2:]
-			}
-		default:
-			return nil, p.newParserError(characterStart+i+1, "bad substitution: no modifier")
-		}
-	case '-':
-		if !envSet {
-			value = s[i+1:]
-		}
-	case '+':
-		if envSet {
-			value = s[i+1:]
-		}
+// skipLine returns the offset of the last byte before the next newline after offset i,
+// or the offset of the last byte if there is no newline. This is used to skip comments.
+func skipLine(d []byte, i int) int {
+	if n := bytes.IndexByte(d[i:], '\n'); n != -1 {
+		return i + n - 1
 	}
 
-	return
+	return len(d) - 1
 }
@@ -2,55 +2,255 @@ package godotenv
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
+	"unicode/utf8"
 )
 
-type parserError struct {
-	lineNumber      int
-	characterNumber int
+// ErrorKind classifies the problems found while parsing an env file.
+type ErrorKind uint8
 
-	line    []byte
-	message string
+const (
+	// KindSyntax is used for syntax errors that don't fit any of the other kinds.
+	KindSyntax ErrorKind = iota
+	// KindInvalidKey is used when a key is empty, or contains characters that are not allowed.
+	KindInvalidKey
+	// KindInvalidValue is used when a value contains characters that are not allowed.
+	KindInvalidValue
+	// KindUnterminated is used when the input ends before a quote, escape sequence or expansion is closed.
+	KindUnterminated
+	// KindInvalidEscape is used for malformed escape sequences.
+	KindInvalidEscape
+	// KindBadSubstitution is used for malformed expansions.
+	KindBadSubstitution
+	// KindUnboundVariable is used for an UnboundVariableError.
+	KindUnboundVariable
+	// KindRequiredVariable is used for a RequiredVariableError.
+	KindRequiredVariable
+	// KindCommand is used for a CommandError.
+	KindCommand
+)
+
+var errorKindNames = [...]string{
+	KindSyntax:           "syntax error",
+	KindInvalidKey:       "invalid key",
+	KindInvalidValue:     "invalid value",
+	KindUnterminated:     "unterminated",
+	KindInvalidEscape:    "invalid escape sequence",
+	KindBadSubstitution:  "bad substitution",
+	KindUnboundVariable:  "unbound variable",
+	KindRequiredVariable: "required variable",
+	KindCommand:          "command failed",
+}
+
+func (k ErrorKind) String() string {
+	if int(k) < len(errorKindNames) {
+		return errorKindNames[k]
+	}
+
+	return fmt.Sprintf("ErrorKind(%d)", k)
+}
+
+// ParseError describes a problem found while parsing an env file. All errors returned by the parser
+// are either a ParseError, or one of the more specific error types that embed it, such as
+// UnboundVariableError, all of which can be retrieved using errors.As.
+type ParseError struct {
+	// Filename is the name of the file being parsed. It is empty when parsing from an io.Reader.
+	Filename string
+	// Line and Column hold the position of the problem, both starting at 1. Column counts
+	// characters rather than bytes.
+	Line   int
+	Column int
+	// Offset is the byte offset of the problem from the start of the input.
+	Offset int
+
+	Message string
+	Kind    ErrorKind
+	// Err is the underlying error that caused the problem, if any.
+	Err error
+
+	line []byte
+}
+
+func (p ParseError) Error() string {
+	location := fmt.Sprintf("line %d", p.Line)
+	if p.Filename != "" {
+		location = fmt.Sprintf("%s:%d", p.Filename, p.Line)
+	}
+
+	return fmt.Sprintf("godotenv: %s on %s\n\t%s\n\t%s%s", p.Message, location, p.line, p.caretIndent(), "^ Right here")
+}
+
+// caretIndent returns the whitespace needed to line the caret up with Column. Tabs are kept
+// so that the caret lines up regardless of the tab width.
+func (p ParseError) caretIndent() string {
+	var sb strings.Builder
+	for i, col := 0, 1; i < len(p.line) && col < p.Column; col++ {
+		r, w := utf8.DecodeRune(p.line[i:])
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+		i += w
+	}
+
+	return sb.String()
+}
+
+func (p ParseError) Unwrap() error {
+	return p.Err
+}
+
+func (p *parser) newParserError(offset int, kind ErrorKind, message string) ParseError {
+	lineStart := bytes.LastIndexByte(p.data[:offset], '\n') + 1
+	lineEnd := len(p.data)
+	if i := bytes.IndexByte(p.data[offset:], '\n'); i != -1 {
+		lineEnd = offset + i
+	}
+
+	return ParseError{
+		Filename: p.filename,
+		Line:     p.firstLine + bytes.Count(p.data[:lineStart], []byte("\n")),
+		Column:   utf8.RuneCount(p.data[lineStart:offset]) + 1,
+		Offset:   p.firstOffset + offset,
+		Message:  message,
+		Kind:     kind,
+		line:     bytes.TrimSuffix(p.data[lineStart:lineEnd], []byte("\r")),
+	}
+}
+
+// multiError holds several errors. errors.Is and errors.As look at each of them, using Unwrap on
+// Go 1.20 and newer, and Is and As before that.
+type multiError []error
+
+func (e multiError) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return strings.Join(messages, "\n")
+}
+
+func (e multiError) Unwrap() []error {
+	return e
 }
 
-func (p parserError) Error() string {
-	return fmt.Sprintf("godotenv: %s on line %d\n\t%s\n\t%s%s", p.message, p.lineNumber, p.line, strings.Repeat(" ", p.characterNumber-1), "^ Right here")
+func (e multiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
 }
 
-func (p *parser) newParserError(characterNumber int, message string) parserError {
-	// this is not ideal, but it's only on error, so should be okay, maybe?
-	line := bytes.SplitN(p.data, []byte("\n"), p.lineNumber+1)[p.lineNumber-1]
-	return parserError{
-		lineNumber:      p.lineNumber,
-		characterNumber: characterNumber,
-		line:            line,
-		message:         message,
+func (e multiError) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
 	}
+
+	return false
+}
+
+// ParseErrors is returned when ParseOptions.AllErrors is set and holds all the problems found.
+// errors.As can be used to find an error of a specific type among them.
+type ParseErrors []error
+
+func (e ParseErrors) Error() string              { return multiError(e).Error() }
+func (e ParseErrors) Unwrap() []error            { return e }
+func (e ParseErrors) Is(target error) bool       { return multiError(e).Is(target) }
+func (e ParseErrors) As(target interface{}) bool { return multiError(e).As(target) }
+
+// UnboundVariableError is returned in strict mode when a variable is referenced that is not set.
+type UnboundVariableError struct {
+	ParseError
+
+	// Name is the name of the variable that is not set.
+	Name string
 }
 
-// nolint: unused
-type unboundVariableError struct {
-	parserError
-	variableName string
+func (e UnboundVariableError) Unwrap() error {
+	return e.ParseError
 }
 
-// nolint: unused
-func (p *parser) newUnboundVariable(characterNumber int, variableName string) unboundVariableError {
-	return unboundVariableError{
-		parserError:  p.newParserError(characterNumber, fmt.Sprintf("%s: unbound variable", variableName)),
-		variableName: variableName,
+func (p *parser) newUnboundVariable(offset int, variableName string) UnboundVariableError {
+	return UnboundVariableError{
+		ParseError: p.newParserError(offset, KindUnboundVariable, fmt.Sprintf("%s: unbound variable", variableName)),
+		Name:       variableName,
 	}
 }
 
-type invalidCharacterError struct {
-	parserError
-	char byte
+// RequiredVariableError is returned when a variable referenced with ${VAR:?message} or
+// ${VAR?message} is not set.
+type RequiredVariableError struct {
+	ParseError
+
+	// Name is the name of the variable that is required.
+	Name string
+	// Message is the message given in the expansion, or a default message if it was empty.
+	Message string
+}
+
+func (e RequiredVariableError) Unwrap() error {
+	return e.ParseError
+}
+
+func (p *parser) newRequiredVariableError(offset int, variableName, message, defaultMessage string) RequiredVariableError {
+	if message == "" {
+		message = defaultMessage
+	}
+
+	return RequiredVariableError{
+		ParseError: p.newParserError(offset, KindRequiredVariable, fmt.Sprintf("%s: %s", variableName, message)),
+		Name:       variableName,
+		Message:    message,
+	}
+}
+
+// CommandError is returned when a command in a command substitution fails. The error returned
+// by the CommandRunner is held in Err.
+type CommandError struct {
+	ParseError
+
+	// Command is the command that failed.
+	Command string
+}
+
+func (e CommandError) Unwrap() error {
+	return e.ParseError
+}
+
+func (p *parser) newCommandError(offset int, command string, err error) CommandError {
+	parseErr := p.newParserError(offset, KindCommand, fmt.Sprintf("command %q failed: %v", command, err))
+	parseErr.Err = err
+
+	return CommandError{
+		ParseError: parseErr,
+		Command:    command,
+	}
+}
+
+// InvalidCharacterError is returned when an unquoted value contains a control character.
+type InvalidCharacterError struct {
+	ParseError
+
+	// Char is the character that is not allowed.
+	Char byte
+}
+
+func (e InvalidCharacterError) Unwrap() error {
+	return e.ParseError
 }
 
-func (p *parser) newInvalidCharacterError(characterNumber int, char byte) invalidCharacterError {
-	return invalidCharacterError{
-		parserError: p.newParserError(characterNumber, fmt.Sprintf("invalid value character: 0x%0.2x", char)),
-		char:        char,
+func (p *parser) newInvalidCharacterError(offset int, char byte) InvalidCharacterError {
+	return InvalidCharacterError{
+		ParseError: p.newParserError(offset, KindInvalidValue, fmt.Sprintf("invalid value character: 0x%0.2x", char)),
+		Char:       char,
 	}
 }
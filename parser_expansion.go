@@ -0,0 +1,822 @@
+package godotenv
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// isShellSpecialVar reports whether the character identifies a special
+// shell variable such as $*.
+func isShellSpecialVar(c uint8) bool {
+	switch c {
+	case '*', '#', '$', '@', '!', '?', '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return true
+	}
+	return false
+}
+
+// isNum reports whether the byte is an ASCII number.
+func isNum(c uint8) bool {
+	return '0' <= c && c <= '9'
+}
+
+// isAlpha reports whether the byte is an ASCII letter.
+func isAlpha(c uint8) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+// isAlphaNum reports whether the byte is an ASCII letter, number, or underscore
+func isAlphaNum(c uint8) bool {
+	return isNum(c) || isAlpha(c) || c == '_'
+}
+
+// resolveParameter resolves the parameter whose '$' is at offset start, returning its value and
+// the number of bytes consumed after the '$'. If the name is enclosed in {}, it's part of a ${}
+// expansion and this will be expanded based on a subset of POSIX specification and bash. Namely:
+// ${VAR}				No parameter expansion
+// $1, ${10}, $@, $#	Positional and special parameters, if they're set through the options.
+// ${VAR:-WORD}			If VAR is empty or unset, use WORD as its value.
+// ${VAR-WORD}			If VAR is unset, use WORD as its value.
+// ${VAR:=WORD}			If VAR is empty or unset, set VAR to WORD and use it as its value.
+// ${VAR=WORD}			If VAR is unset, set VAR to WORD and use it as its value.
+// ${VAR:+WORD}			If VAR is not empty, use WORD as its value.
+// ${VAR+WORD}			If VAR is set, use WORD as its value.
+// ${VAR:?WORD}			If VAR is empty or unset, abort with WORD as the error message.
+// ${VAR?WORD}			If VAR is unset, abort with WORD as the error message.
+// ${#VAR}				The length of VAR in characters.
+// ${VAR#PATTERN}		Remove the shortest prefix of VAR matching PATTERN.
+// ${VAR##PATTERN}		Remove the longest prefix of VAR matching PATTERN.
+// ${VAR%PATTERN}		Remove the shortest suffix of VAR matching PATTERN.
+// ${VAR%%PATTERN}		Remove the longest suffix of VAR matching PATTERN.
+// ${VAR/PATTERN/WORD}	Replace the first longest match of PATTERN in VAR with WORD.
+// ${VAR//PATTERN/WORD}	Replace all longest matches of PATTERN in VAR with WORD.
+// ${VAR:OFFSET}		The characters of VAR starting at OFFSET.
+// ${VAR:OFFSET:LENGTH}	Up to LENGTH characters of VAR starting at OFFSET.
+// ${VAR^}, ${VAR^^}	Convert the first or all characters of VAR to upper case.
+// ${VAR,}, ${VAR,,}	Convert the first or all characters of VAR to lower case.
+// $(COMMAND)			The output of COMMAND, if a CommandRunner is configured.
+// https://steinbaugh.com/posts/posix.html#default-value
+// https://www.gnu.org/software/bash/manual/html_node/Shell-Parameter-Expansion.html
+//
+// quoted reports whether the parameter is inside double quotes. When eval is false the parameter
+// is only scanned, which is used to skip over words that are not used, e.g. the WORD in
+// ${VAR:-WORD} when VAR is set. This ensures there are no side effects from words that are skipped.
+func (p *parser) resolveParameter(start int, quoted, eval bool) (value []byte, skip int, err error) {
+	i := start + 1
+	if i >= len(p.data) {
+		return nil, 0, nil
+	}
+
+	switch c := p.data[i]; {
+	case c == '{':
+		value, end, err := p.expandBraces(start, quoted, eval)
+		return value, end - start, err
+	case c == '(' && p.opts.CommandRunner != nil:
+		value, end, err := p.substituteCommand(start, eval)
+		return value, end - start, err
+	case isShellSpecialVar(c):
+		if !eval {
+			return nil, 1, nil
+		}
+
+		value, err := p.lookupParameter(start, p.data[i:i+1])
+		return value, 1, err
+	default:
+		// Scan alphanumerics.
+		for ; i < len(p.data) && isAlphaNum(p.data[i]); i++ {
+		}
+
+		name := p.data[start+1 : i]
+		if len(name) == 0 || !eval {
+			return nil, len(name), nil
+		}
+
+		value, err := p.lookupParameter(start, name)
+		return value, len(name), err
+	}
+}
+
+// lookupParameter looks up a parameter whose value is used as is, failing if it's not set in strict mode.
+func (p *parser) lookupParameter(start int, name []byte) ([]byte, error) {
+	value, envSet := p.lookupName(name)
+	if !envSet && p.opts.Strict {
+		return nil, p.newUnboundVariable(start, string(name))
+	}
+
+	return value, nil
+}
+
+// positionalArgs returns the positional parameters if name is @ or *, which expand to all of them.
+func (p *parser) positionalArgs(name []byte) (args []string, ok bool) {
+	if len(name) == 1 && (name[0] == '@' || name[0] == '*') {
+		return p.opts.Args, true
+	}
+
+	return nil, false
+}
+
+// mapParameter returns the value of the parameter converted by fn. For $@ and $* each positional
+// parameter is converted on its own, and the results are joined with spaces.
+func (p *parser) mapParameter(start int, name []byte, fn func(string) string) ([]byte, error) {
+	if args, ok := p.positionalArgs(name); ok {
+		values := make([]string, len(args))
+		for i, arg := range args {
+			values[i] = fn(arg)
+		}
+
+		return []byte(strings.Join(values, " ")), nil
+	}
+
+	value, err := p.lookupParameter(start, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fn(string(value))), nil
+}
+
+// lookupName looks up the value of a variable, or of a special parameter using the arguments in the
+// options. Special parameters that can't be determined, such as $? and $!, are always empty.
+func (p *parser) lookupName(name []byte) (value []byte, set bool) {
+	if !isSpecialName(name) {
+		return p.lookupEnv(name)
+	}
+
+	switch name[0] {
+	case '@', '*':
+		return []byte(strings.Join(p.opts.Args, " ")), true
+	case '#':
+		return []byte(strconv.Itoa(len(p.opts.Args))), true
+	case '$':
+		if p.opts.PID == 0 {
+			return nil, true
+		}
+
+		return []byte(strconv.Itoa(p.opts.PID)), true
+	case '0':
+		if len(name) == 1 {
+			return []byte(p.opts.Arg0), true
+		}
+	}
+
+	if !isNum(name[0]) {
+		return nil, true
+	}
+
+	n, err := strconv.Atoi(string(name))
+	if err != nil || n < 1 || n > len(p.opts.Args) {
+		return nil, false
+	}
+
+	return []byte(p.opts.Args[n-1]), true
+}
+
+// isSpecialName reports whether the name is a positional parameter, such as 1, or a special parameter, such as @.
+func isSpecialName(name []byte) bool {
+	if len(name) == 1 && isShellSpecialVar(name[0]) {
+		return true
+	}
+
+	for _, c := range name {
+		if !isNum(c) {
+			return false
+		}
+	}
+
+	return len(name) > 0
+}
+
+// scanName returns the offset of the first byte after the variable name that starts at offset i.
+func (p *parser) scanName(i int) int {
+	for ; i < len(p.data) && isAlphaNum(p.data[i]); i++ {
+	}
+
+	return i
+}
+
+// expandBraces expands the ${...} expansion whose '$' is at offset start. It returns the
+// expanded value and the offset of the closing brace.
+func (p *parser) expandBraces(start int, quoted, eval bool) (value []byte, end int, err error) {
+	i := start + 2
+	if i+1 < len(p.data) && p.data[i] == '#' && (isAlphaNum(p.data[i+1]) || isArgsLength(p.data[i+1:])) {
+		return p.expandLength(start, eval)
+	}
+
+	switch {
+	case i >= len(p.data):
+	case isNum(p.data[i]):
+		for ; i < len(p.data) && isNum(p.data[i]); i++ {
+		}
+	case isShellSpecialVar(p.data[i]):
+		i++
+	default:
+		i = p.scanName(i)
+	}
+
+	if i >= len(p.data) {
+		return nil, 0, p.newParserError(start+1, KindUnterminated, "unexpected EOF while looking for matching '}'")
+	}
+
+	name := p.data[start+2 : i]
+	switch {
+	case len(name) == 0 && p.data[i] == '}':
+		return nil, i, nil // bad syntax; eat "${}"
+	case len(name) == 0:
+		return nil, 0, p.newParserError(i, KindBadSubstitution, "bad substitution")
+	}
+
+	switch op := p.data[i]; op {
+	case '}':
+		if !eval {
+			return nil, i, nil
+		}
+
+		value, err = p.lookupParameter(start, name)
+		return value, i, err
+	case ':':
+		if i+1 < len(p.data) && !isDefaultModifier(p.data[i+1]) {
+			return p.expandSubstring(start, name, i+1, quoted, eval)
+		}
+
+		return p.expandDefault(start, name, i+1, true, quoted, eval)
+	case '-', '=', '?', '+':
+		return p.expandDefault(start, name, i, false, quoted, eval)
+	case '#', '%':
+		return p.expandTrim(start, name, i, quoted, eval)
+	case '/':
+		return p.expandReplace(start, name, i, quoted, eval)
+	case '^', ',':
+		return p.expandCase(start, name, i, eval)
+	default:
+		return nil, 0, p.newParserError(i, KindBadSubstitution, "bad substitution")
+	}
+}
+
+// substituteCommand runs the command in the $(...) whose '$' is at offset start, returning its
+// output without trailing newlines and the offset of the closing parenthesis.
+func (p *parser) substituteCommand(start int, eval bool) (value []byte, end int, err error) {
+	line := p.lineNumber
+
+	end, err = p.scanCommand(start + 2)
+	if err != nil || !eval {
+		return nil, end, err
+	}
+
+	env := p.env
+	if p.scope != nil {
+		env = p.scope
+	}
+
+	command := string(p.data[start+2 : end])
+	output, err := p.opts.CommandRunner.RunCommand(command, env)
+	if err != nil {
+		// report the error on the line the command substitution starts on
+		p.lineNumber = line
+		return nil, 0, p.newCommandError(start, command, err)
+	}
+
+	return bytes.TrimRight(output, "\r\n"), end, nil
+}
+
+// scanCommand returns the offset of the parenthesis that closes the command starting at offset
+// start, skipping over quoted strings and nested parentheses.
+func (p *parser) scanCommand(start int) (end int, err error) {
+	var (
+		depth int
+		quote byte
+	)
+
+	for i := start; i < len(p.data); i++ {
+		c := p.data[i]
+		if c == '\n' {
+			p.lineNumber++
+		}
+
+		switch {
+		case c == '\\' && quote != '\'':
+			i++
+			if i < len(p.data) && p.data[i] == '\n' {
+				p.lineNumber++
+			}
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'', c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			if depth == 0 {
+				return i, nil
+			}
+			depth--
+		}
+	}
+
+	return 0, p.newParserError(start-1, KindUnterminated, "unexpected EOF while looking for matching ')'")
+}
+
+// isDefaultModifier reports whether the byte is a modifier that can follow the colon in e.g. ${VAR:-WORD}.
+func isDefaultModifier(c byte) bool {
+	return c == '-' || c == '=' || c == '?' || c == '+'
+}
+
+// expandDefault expands ${VAR-WORD}, ${VAR=WORD}, ${VAR?WORD} and ${VAR+WORD}, with or without a colon,
+// where i is the offset of the modifier.
+func (p *parser) expandDefault(start int, name []byte, i int, colon, quoted, eval bool) (value []byte, end int, err error) {
+	if i >= len(p.data) {
+		return nil, 0, p.newParserError(start+1, KindUnterminated, "unexpected EOF while looking for matching '}'")
+	}
+
+	var envSet bool
+	if eval {
+		value, envSet = p.lookupName(name)
+	}
+
+	// empty reports whether the modifier considers the variable unset
+	empty := !envSet || colon && len(value) == 0
+
+	op := p.data[i]
+	switch op {
+	case '-', '=', '?':
+		word, end, err := p.expandWord(i+1, "}", quoted, eval && empty, false)
+		if err != nil || !eval || !empty {
+			return value, end, err
+		}
+
+		switch op {
+		case '=':
+			if isSpecialName(name) {
+				return nil, 0, p.newParserError(start+2, KindBadSubstitution, fmt.Sprintf("$%s: cannot assign in this way", name))
+			}
+
+			p.env[string(name)] = string(word)
+			if p.define != nil {
+				definition := Definition{Value: string(word), Filename: p.filename, Line: p.lineNumber, Raw: p.statementRaw()}
+				if err = p.define(string(name), definition); err != nil {
+					return nil, 0, err
+				}
+			}
+		case '?':
+			message, defaultMessage := string(word), "parameter not set"
+			if colon {
+				defaultMessage = "parameter null or not set"
+			}
+
+			return nil, 0, p.newRequiredVariableError(start, string(name), message, defaultMessage)
+		}
+
+		return word, end, nil
+	case '+':
+		word, end, err := p.expandWord(i+1, "}", quoted, eval && !empty, false)
+		if empty {
+			word = nil
+		}
+
+		return word, end, err
+	default:
+		return nil, 0, p.newParserError(i, KindBadSubstitution, "bad substitution: no modifier")
+	}
+}
+
+// expandLength expands ${#VAR}, the number of characters in VAR, and ${#@} and ${#*}, the number
+// of positional parameters.
+func (p *parser) expandLength(start int, eval bool) (value []byte, end int, err error) {
+	end = start + 3
+	if isArgsLength(p.data[end:]) {
+		end++
+	} else if isNum(p.data[end]) {
+		for ; end < len(p.data) && isNum(p.data[end]); end++ {
+		}
+	} else {
+		end = p.scanName(end)
+	}
+
+	if end >= len(p.data) || p.data[end] != '}' {
+		return nil, 0, p.newParserError(end, KindBadSubstitution, "bad substitution")
+	}
+
+	if !eval {
+		return nil, end, nil
+	}
+
+	name := p.data[start+3 : end]
+	if args, ok := p.positionalArgs(name); ok {
+		return []byte(strconv.Itoa(len(args))), end, nil
+	}
+
+	value, err = p.lookupParameter(start, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return []byte(strconv.Itoa(utf8.RuneCount(value))), end, nil
+}
+
+// isArgsLength reports whether b starts with the @} or *} of ${#@} or ${#*}.
+func isArgsLength(b []byte) bool {
+	return len(b) >= 2 && (b[0] == '@' || b[0] == '*') && b[1] == '}'
+}
+
+// expandTrim expands ${VAR#PATTERN}, ${VAR##PATTERN}, ${VAR%PATTERN} and ${VAR%%PATTERN}, where i is
+// the offset of the first modifier.
+func (p *parser) expandTrim(start int, name []byte, i int, quoted, eval bool) (value []byte, end int, err error) {
+	op := p.data[i]
+	longest := i+1 < len(p.data) && p.data[i+1] == op
+	if longest {
+		i++
+	}
+
+	pattern, end, err := p.expandWord(i+1, "}", quoted, eval, true)
+	if err != nil || !eval {
+		return nil, end, err
+	}
+
+	value, err = p.mapParameter(start, name, func(s string) string {
+		if op == '#' {
+			return trimPrefixPattern(s, string(pattern), longest)
+		}
+
+		return trimSuffixPattern(s, string(pattern), longest)
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return value, end, nil
+}
+
+// expandReplace expands ${VAR/PATTERN/WORD} and ${VAR//PATTERN/WORD}, where i is the offset of the first slash.
+// A PATTERN starting with # or % only matches at the start or end of VAR respectively.
+func (p *parser) expandReplace(start int, name []byte, i int, quoted, eval bool) (value []byte, end int, err error) {
+	var anchor byte
+	if i+1 < len(p.data) {
+		anchor = p.data[i+1]
+	}
+
+	switch anchor {
+	case '/', '#', '%':
+		i++
+	default:
+		anchor = 0
+	}
+
+	pattern, end, err := p.expandWord(i+1, "/}", quoted, eval, true)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var replacement []byte
+	if p.data[end] == '/' {
+		replacement, end, err = p.expandWord(end+1, "}", quoted, eval, false)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if !eval {
+		return nil, end, nil
+	}
+
+	value, err = p.mapParameter(start, name, func(s string) string {
+		switch anchor {
+		case '#':
+			return replacePrefixPattern(s, string(pattern), string(replacement))
+		case '%':
+			return replaceSuffixPattern(s, string(pattern), string(replacement))
+		}
+
+		return replacePattern(s, string(pattern), string(replacement), anchor == '/')
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return value, end, nil
+}
+
+// expandSubstring expands ${VAR:OFFSET} and ${VAR:OFFSET:LENGTH}, where i is the offset of OFFSET.
+// Both OFFSET and LENGTH are expanded, and must result in an integer. A negative OFFSET counts
+// from the end of VAR, as does a negative LENGTH. For $@ and $* OFFSET and LENGTH count positional
+// parameters rather than characters, and LENGTH can't be negative.
+func (p *parser) expandSubstring(start int, name []byte, i int, quoted, eval bool) (value []byte, end int, err error) {
+	offsetWord, end, err := p.expandWord(i, ":}", quoted, eval, false)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	lengthStart := -1
+	var lengthWord []byte
+	if p.data[end] == ':' {
+		lengthStart = end + 1
+		lengthWord, end, err = p.expandWord(lengthStart, "}", quoted, eval, false)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	if !eval {
+		return nil, end, nil
+	}
+
+	// the substring is taken from the characters of VAR, or from the positional parameters
+	// for $@ and $*, starting with $0
+	var elems []string
+	sep := ""
+	if args, ok := p.positionalArgs(name); ok {
+		elems = append([]string{p.opts.Arg0}, args...)
+		sep = " "
+	} else {
+		value, err = p.lookupParameter(start, name)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		elems = strings.Split(string(value), "")
+	}
+
+	offset, ok := parseSubstringInt(offsetWord)
+	if !ok {
+		return nil, 0, p.newParserError(i, KindBadSubstitution, "bad substitution: invalid offset")
+	}
+	if offset < 0 {
+		offset += len(elems)
+	}
+	if offset < 0 || offset > len(elems) {
+		return nil, end, nil
+	}
+
+	length := len(elems) - offset
+	if lengthStart != -1 {
+		if length, ok = parseSubstringInt(lengthWord); !ok {
+			return nil, 0, p.newParserError(lengthStart, KindBadSubstitution, "bad substitution: invalid length")
+		}
+
+		if length < 0 && sep == "" {
+			length += len(elems) - offset
+		}
+		if length < 0 {
+			return nil, 0, p.newParserError(lengthStart, KindBadSubstitution, "bad substitution: substring expression < 0")
+		}
+
+		if offset+length > len(elems) {
+			length = len(elems) - offset
+		}
+	}
+
+	return []byte(strings.Join(elems[offset:offset+length], sep)), end, nil
+}
+
+// parseSubstringInt parses the offset or length of a substring expansion, which may be surrounded by
+// whitespace and parentheses, e.g. ${VAR: -1} or ${VAR:(-1)}. An empty string is parsed as 0.
+func parseSubstringInt(b []byte) (int, bool) {
+	s := strings.TrimSpace(string(b))
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	if s == "" {
+		return 0, true
+	}
+
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// expandCase expands ${VAR^}, ${VAR^^}, ${VAR,} and ${VAR,,}, where i is the offset of the first modifier.
+func (p *parser) expandCase(start int, name []byte, i int, eval bool) (value []byte, end int, err error) {
+	op := p.data[i]
+	all := i+1 < len(p.data) && p.data[i+1] == op
+	end = i + 1
+	if all {
+		end++
+	}
+
+	if end >= len(p.data) || p.data[end] != '}' {
+		return nil, 0, p.newParserError(end, KindBadSubstitution, "bad substitution")
+	}
+
+	if !eval {
+		return nil, end, nil
+	}
+
+	convert := strings.ToUpper
+	if op == ',' {
+		convert = strings.ToLower
+	}
+
+	value, err = p.mapParameter(start, name, func(s string) string {
+		if all || s == "" {
+			return convert(s)
+		}
+
+		_, w := utf8.DecodeRuneInString(s)
+		return convert(s[:w]) + s[w:]
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return value, end, nil
+}
+
+// expandWord expands the word in a ${...} expansion that starts at offset start, returning the
+// expanded word and the offset of the byte in stop that ends it. Quotes, escapes and nested
+// expansions in the word are handled the same way as they are in a value, where quoted reports
+// whether the expansion itself is in double quotes. If pattern is set, the word is expanded into
+// a pattern where quoted or escaped characters are matched literally.
+func (p *parser) expandWord(start int, stop string, quoted, eval, pattern bool) (value []byte, end int, err error) {
+	var (
+		depth  int
+		single bool
+		double = quoted
+	)
+
+	for i := start; i < len(p.data); i++ {
+		c := p.data[i]
+
+		if c == '\n' {
+			p.lineNumber++
+		}
+
+		if single {
+			if c == '\'' {
+				single = false
+			} else {
+				value = appendLiteral(value, pattern, c)
+			}
+
+			continue
+		}
+
+		// literal reports whether we're in quotes that were opened in the word
+		literal := double != quoted
+
+		switch {
+		case c == '\\':
+			if i+1 >= len(p.data) {
+				return nil, 0, p.newParserError(i, KindUnterminated, "incomplete escape sequence")
+			}
+
+			i++
+			if !double || pattern && isPatternSpecial(p.data[i]) {
+				if p.data[i] == '\n' {
+					p.lineNumber++
+				}
+				value = appendLiteral(value, pattern, p.data[i])
+				continue
+			}
+
+			var w int
+			value, w, err = p.unescapeDouble(value, i)
+			if err != nil {
+				return nil, 0, err
+			}
+			i += w
+		case c == '\'':
+			if double {
+				value = appendLiteral(value, pattern, c)
+			} else {
+				single = true
+			}
+		case c == '"':
+			double = !double
+		case c == '$':
+			res, w, err := p.resolveParameter(i, double, eval)
+			if err != nil {
+				return nil, 0, err
+			}
+			value = appendLiteral(value, pattern && literal, res...)
+			i += w
+		case literal:
+			value = appendLiteral(value, pattern, c)
+		case depth == 0 && strings.IndexByte(stop, c) != -1:
+			return value, i, nil
+		case c == '{':
+			depth++
+			value = append(value, c)
+		case c == '}':
+			depth--
+			value = append(value, c)
+		default:
+			value = append(value, c)
+		}
+	}
+
+	return nil, 0, p.newParserError(start, KindUnterminated, "unexpected EOF while looking for matching '}'")
+}
+
+// appendLiteral appends the bytes to value, escaping characters that have a special meaning in
+// patterns if pattern is set, so that they are matched literally.
+func appendLiteral(value []byte, pattern bool, b ...byte) []byte {
+	if !pattern {
+		return append(value, b...)
+	}
+
+	for _, c := range b {
+		if isPatternSpecial(c) {
+			value = append(value, '\\')
+		}
+		value = append(value, c)
+	}
+
+	return value
+}
+
+// unescapeDouble appends the character escaped by the backslash before offset i in a double quoted
+// string to value. It returns the number of bytes consumed in addition to the one at offset i.
+// Besides the usual single character escapes, the following escapes are supported:
+// \xHH			The byte with the value of 1 or 2 hexadecimal digits.
+// \uHHHH		The unicode character with the value of 1 to 4 hexadecimal digits.
+// \UHHHHHHHH	The unicode character with the value of 1 to 8 hexadecimal digits.
+// \NNN			The byte with the value of 1 to 3 octal digits.
+func (p *parser) unescapeDouble(value []byte, i int) ([]byte, int, error) {
+	switch c := p.data[i]; c {
+	case 'a':
+		value = append(value, '\a')
+	case 'b':
+		value = append(value, '\b')
+	case 'f':
+		value = append(value, '\f')
+	case 'n':
+		value = append(value, '\n')
+	case 'r':
+		value = append(value, '\r')
+	case 't':
+		value = append(value, '\t')
+	case 'v':
+		value = append(value, '\v')
+	case 'x':
+		n, w := p.scanEscapeDigits(i+1, 2, 16)
+		if w == 0 {
+			return nil, 0, p.newParserError(i, KindInvalidEscape, "invalid escape sequence: \\x used with no following hex digits")
+		}
+
+		return append(value, byte(n)), w, nil
+	case 'u', 'U':
+		maxDigits := 4
+		if c == 'U' {
+			maxDigits = 8
+		}
+
+		n, w := p.scanEscapeDigits(i+1, maxDigits, 16)
+		if w == 0 {
+			return nil, 0, p.newParserError(i, KindInvalidEscape, fmt.Sprintf("invalid escape sequence: \\%c used with no following hex digits", c))
+		}
+
+		r := rune(n)
+		if !utf8.ValidRune(r) {
+			return nil, 0, p.newParserError(i, KindInvalidEscape, fmt.Sprintf("invalid escape sequence: \\%c%s is not a valid unicode character", c, p.data[i+1:i+1+w]))
+		}
+
+		var buf [utf8.UTFMax]byte
+		return append(value, buf[:utf8.EncodeRune(buf[:], r)]...), w, nil
+	case '0', '1', '2', '3', '4', '5', '6', '7':
+		n, w := p.scanEscapeDigits(i, 3, 8)
+		if n > 0xff {
+			return nil, 0, p.newParserError(i, KindInvalidEscape, fmt.Sprintf("invalid escape sequence: \\%s is larger than 255", p.data[i:i+w]))
+		}
+
+		return append(value, byte(n)), w - 1, nil
+	default:
+		if c == '\n' {
+			p.lineNumber++
+		}
+		value = append(value, c)
+	}
+
+	return value, 0, nil
+}
+
+// scanEscapeDigits parses up to maxDigits digits in the given base starting at offset i. It returns
+// the parsed value and the number of digits consumed.
+func (p *parser) scanEscapeDigits(i, maxDigits int, base uint32) (n uint32, w int) {
+	for ; w < maxDigits && i+w < len(p.data); w++ {
+		d := digitValue(p.data[i+w])
+		if d >= base {
+			break
+		}
+
+		n = n*base + d
+	}
+
+	return n, w
+}
+
+// digitValue returns the value of the hexadecimal digit, or 16 if it's not a hexadecimal digit.
+func digitValue(c byte) uint32 {
+	switch {
+	case '0' <= c && c <= '9':
+		return uint32(c - '0')
+	case 'a' <= c && c <= 'f':
+		return uint32(c - 'a' + 10)
+	case 'A' <= c && c <= 'F':
+		return uint32(c - 'A' + 10)
+	}
+
+	return 16
+}
@@ -0,0 +1,366 @@
+package godotenv
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// isPatternSpecial reports whether the byte has a special meaning in a pattern.
+func isPatternSpecial(c byte) bool {
+	switch c {
+	case '*', '?', '[', ']', '\\':
+		return true
+	}
+	return false
+}
+
+// A glob is a compiled shell pattern. Patterns support * to match any string, ? to match any
+// single character, [...] to match any character in a set, and \ to match the character following
+// it literally.
+//
+// Globs are matched by tracking the set of tokens that can be reached after each character, so
+// matching takes time proportional to the length of the string times the length of the pattern,
+// however many stars the pattern holds.
+type glob []globToken
+
+type globToken struct {
+	// kind is '*', '?' or '[', or 0 to match r literally.
+	kind byte
+	r    rune
+	// class holds the bracket expression for '['.
+	class string
+}
+
+// compileGlob compiles the shell pattern into a glob.
+func compileGlob(pattern string) glob {
+	var g glob
+	for len(pattern) > 0 {
+		switch c := pattern[0]; c {
+		case '*':
+			// consecutive stars match the same as a single one
+			if len(g) == 0 || g[len(g)-1].kind != '*' {
+				g = append(g, globToken{kind: '*'})
+			}
+			pattern = pattern[1:]
+			continue
+		case '?':
+			g = append(g, globToken{kind: '?'})
+			pattern = pattern[1:]
+			continue
+		case '[':
+			// without a closing bracket, the bracket is matched literally
+			if _, n, ok := matchClass(pattern, 0); ok {
+				g = append(g, globToken{kind: '[', class: pattern[:n]})
+				pattern = pattern[n:]
+				continue
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+		}
+
+		r, w := utf8.DecodeRuneInString(pattern)
+		g = append(g, globToken{r: r})
+		pattern = pattern[w:]
+	}
+
+	return g
+}
+
+// matches reports whether the token, other than a star, matches r.
+func (t globToken) matches(r rune) bool {
+	switch t.kind {
+	case '?':
+		return true
+	case '[':
+		matched, _, _ := matchClass(t.class, r)
+		return matched
+	}
+
+	return t.r == r
+}
+
+// reverse returns the glob that matches the strings this glob matches, with their characters reversed.
+func (g glob) reverse() glob {
+	reversed := make(glob, len(g))
+	for i, t := range g {
+		reversed[len(g)-1-i] = t
+	}
+
+	return reversed
+}
+
+// closure adds the states that can be reached from the states in set without consuming a
+// character, i.e. by matching a star with the empty string. A state is the index of the next
+// token to match, and set holds the start of the match for each state that is reached, or -1.
+// The earliest start is kept when a state can be reached in more than one way.
+func (g glob) closure(set []int) {
+	for i, t := range g {
+		if t.kind == '*' && set[i] >= 0 && (set[i+1] < 0 || set[i] < set[i+1]) {
+			set[i+1] = set[i]
+		}
+	}
+}
+
+// step returns the states that can be reached from the states in set by consuming r.
+func (g glob) step(set, next []int, r rune) {
+	for i := range next {
+		next[i] = -1
+	}
+
+	for i, t := range g {
+		start := set[i]
+		if start < 0 {
+			continue
+		}
+
+		to := i + 1
+		if t.kind == '*' {
+			to = i
+		} else if !t.matches(r) {
+			continue
+		}
+
+		if next[to] < 0 || start < next[to] {
+			next[to] = start
+		}
+	}
+
+	g.closure(next)
+}
+
+// newStates returns a set of states with none reached.
+func (g glob) newStates() []int {
+	set := make([]int, len(g)+1)
+	for i := range set {
+		set[i] = -1
+	}
+
+	return set
+}
+
+// prefixes calls fn with the length of each prefix of s that the glob matches, shortest first,
+// until fn returns false.
+func (g glob) prefixes(s string, fn func(n int) bool) {
+	set, next := g.newStates(), g.newStates()
+	set[0] = 0
+	g.closure(set)
+
+	for i := 0; ; {
+		if set[len(g)] >= 0 && !fn(i) {
+			return
+		}
+		if i == len(s) || !anyReached(set) {
+			return
+		}
+
+		r, w := utf8.DecodeRuneInString(s[i:])
+		g.step(set, next, r)
+		set, next = next, set
+		i += w
+	}
+}
+
+// suffixes calls fn with the length of each suffix of s that the glob matches, shortest first,
+// until fn returns false.
+func (g glob) suffixes(s string, fn func(n int) bool) {
+	g = g.reverse()
+	set, next := g.newStates(), g.newStates()
+	set[0] = 0
+	g.closure(set)
+
+	for n := 0; ; {
+		if set[len(g)] >= 0 && !fn(n) {
+			return
+		}
+		if n == len(s) || !anyReached(set) {
+			return
+		}
+
+		r, w := utf8.DecodeLastRuneInString(s[:len(s)-n])
+		g.step(set, next, r)
+		set, next = next, set
+		n += w
+	}
+}
+
+// leftmostLongest returns the start and end of the leftmost non-empty match of the glob in s at or
+// after offset from, preferring the longest match at that start. It returns -1, -1 if there is none.
+// All the starts are tried in a single pass over s.
+func (g glob) leftmostLongest(s string, from int) (start, end int) {
+	start, end = -1, -1
+	set, next := g.newStates(), g.newStates()
+
+	for i := from; ; {
+		if start < 0 && set[0] < 0 {
+			set[0] = i
+			g.closure(set)
+		}
+
+		if first := set[len(g)]; first >= 0 && first < i && (start < 0 || first <= start) {
+			start, end = first, i
+		}
+
+		// threads that started after the match can't improve on it
+		if start >= 0 {
+			for j, first := range set {
+				if first > start {
+					set[j] = -1
+				}
+			}
+		}
+
+		if i == len(s) || start >= 0 && !anyReached(set) {
+			return start, end
+		}
+
+		r, w := utf8.DecodeRuneInString(s[i:])
+		g.step(set, next, r)
+		set, next = next, set
+		i += w
+	}
+}
+
+func anyReached(set []int) bool {
+	for _, start := range set {
+		if start >= 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchClass reports whether r matches the bracket expression at the start of the pattern, along
+// with the width of the bracket expression. ok is false if the bracket expression is not closed.
+func matchClass(pattern string, r rune) (matched bool, width int, ok bool) {
+	i := 1
+	negate := i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^')
+	if negate {
+		i++
+	}
+
+	for first := true; i < len(pattern); first = false {
+		if pattern[i] == ']' && !first {
+			return matched != negate, i + 1, true
+		}
+
+		lo, w := classRune(pattern[i:])
+		i += w
+
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, w = classRune(pattern[i+1:])
+			i += 1 + w
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return false, 0, false
+}
+
+// classRune decodes the possibly escaped rune at the start of s in a bracket expression.
+func classRune(s string) (rune, int) {
+	if s[0] == '\\' && len(s) > 1 {
+		r, w := utf8.DecodeRuneInString(s[1:])
+		return r, w + 1
+	}
+
+	return utf8.DecodeRuneInString(s)
+}
+
+// trimPrefixPattern removes the shortest, or longest, prefix of s that matches the pattern.
+func trimPrefixPattern(s, pattern string, longest bool) string {
+	n := -1
+	compileGlob(pattern).prefixes(s, func(i int) bool {
+		n = i
+		return longest
+	})
+
+	if n < 0 {
+		return s
+	}
+
+	return s[n:]
+}
+
+// trimSuffixPattern removes the shortest, or longest, suffix of s that matches the pattern.
+func trimSuffixPattern(s, pattern string, longest bool) string {
+	n := -1
+	compileGlob(pattern).suffixes(s, func(i int) bool {
+		n = i
+		return longest
+	})
+
+	if n < 0 {
+		return s
+	}
+
+	return s[:len(s)-n]
+}
+
+// replacePattern replaces the first, or all, longest non-empty matches of the pattern in s with
+// the replacement.
+func replacePattern(s, pattern, replacement string, all bool) string {
+	if pattern == "" {
+		return s
+	}
+
+	g := compileGlob(pattern)
+
+	var sb strings.Builder
+	for i := 0; i < len(s); {
+		start, end := g.leftmostLongest(s, i)
+		if start < 0 {
+			sb.WriteString(s[i:])
+			break
+		}
+
+		sb.WriteString(s[i:start])
+		sb.WriteString(replacement)
+		i = end
+
+		if !all {
+			sb.WriteString(s[i:])
+			break
+		}
+	}
+
+	return sb.String()
+}
+
+// replacePrefixPattern replaces the longest prefix of s that matches the pattern with the replacement.
+// An empty pattern matches the empty prefix, so the replacement is prepended.
+func replacePrefixPattern(s, pattern, replacement string) string {
+	n := -1
+	compileGlob(pattern).prefixes(s, func(i int) bool {
+		n = i
+		return true
+	})
+
+	if n < 0 {
+		return s
+	}
+
+	return replacement + s[n:]
+}
+
+// replaceSuffixPattern replaces the longest suffix of s that matches the pattern with the replacement.
+// An empty pattern matches the empty suffix, so the replacement is appended.
+func replaceSuffixPattern(s, pattern, replacement string) string {
+	n := -1
+	compileGlob(pattern).suffixes(s, func(i int) bool {
+		n = i
+		return true
+	})
+
+	if n < 0 {
+		return s
+	}
+
+	return s[:len(s)-n] + replacement
+}
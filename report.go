@@ -0,0 +1,70 @@
+package godotenv
+
+import "fmt"
+
+// redacted replaces values in a redacted Report.
+const redacted = "[REDACTED]"
+
+// Report describes what LoadWithReport did with each variable in the env files. The entries are
+// sorted by key.
+type Report struct {
+	// Applied holds the variables that were set, and didn't exist in the environment before.
+	Applied []ReportEntry
+	// Skipped holds the variables that were not set, because they already exist in the environment.
+	Skipped []ReportEntry
+	// Overridden holds the variables that were set, replacing the value that already existed in the
+	// environment. This only happens when LoadOptions.Overload is set.
+	Overridden []ReportEntry
+}
+
+// ReportEntry describes a single variable in a Report.
+type ReportEntry struct {
+	Key string
+	// Definition is the definition from the env files.
+	Definition
+	// EnvValue is the value of the variable in the environment before loading. It is empty for
+	// applied variables.
+	EnvValue string
+}
+
+// String returns the variable along with where it's defined, e.g. "PORT=8080 (.env:3)". The value
+// in the environment is added when it's set.
+func (e ReportEntry) String() string {
+	if e.EnvValue != "" {
+		return fmt.Sprintf("%s=%s (%s, environment: %s)", e.Key, e.Value, e.Definition, e.EnvValue)
+	}
+
+	return fmt.Sprintf("%s=%s (%s)", e.Key, e.Value, e.Definition)
+}
+
+// Redact returns a copy of the report with all values replaced, so that it can be logged
+// without leaking secrets. Empty values are left as is.
+func (r Report) Redact() Report {
+	return Report{
+		Applied:    redactEntries(r.Applied),
+		Skipped:    redactEntries(r.Skipped),
+		Overridden: redactEntries(r.Overridden),
+	}
+}
+
+func redactEntries(entries []ReportEntry) []ReportEntry {
+	if entries == nil {
+		return nil
+	}
+
+	redactedEntries := make([]ReportEntry, len(entries))
+	for i, entry := range entries {
+		if entry.Value != "" {
+			entry.Value = redacted
+		}
+		if entry.Raw != "" {
+			entry.Raw = entry.Key + "=" + redacted
+		}
+		if entry.EnvValue != "" {
+			entry.EnvValue = redacted
+		}
+		redactedEntries[i] = entry
+	}
+
+	return redactedEntries
+}
@@ -0,0 +1,126 @@
+package godotenv_test
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestLoadWithReport(t *testing.T) {
+	plain := func(line int, value string) godotenv.Definition {
+		return godotenv.Definition{Value: value, Filename: "fixtures/plain.env", Line: line, Raw: "OPTION_" + string(rune('A'+line-1)) + "=" + value}
+	}
+
+	tests := []struct {
+		name     string
+		overload bool
+		expected godotenv.Report
+	}{
+		{
+			name: "load",
+			expected: godotenv.Report{
+				Applied: []godotenv.ReportEntry{{Key: "OPTION_C", Definition: plain(3, "")}},
+				Skipped: []godotenv.ReportEntry{
+					{Key: "OPTION_A", Definition: plain(1, "1"), EnvValue: "from env"},
+					{Key: "OPTION_B", Definition: plain(2, "2"), EnvValue: ""},
+				},
+			},
+		},
+		{
+			name:     "overload",
+			overload: true,
+			expected: godotenv.Report{
+				Applied: []godotenv.ReportEntry{{Key: "OPTION_C", Definition: plain(3, "")}},
+				Overridden: []godotenv.ReportEntry{
+					{Key: "OPTION_A", Definition: plain(1, "1"), EnvValue: "from env"},
+					{Key: "OPTION_B", Definition: plain(2, "2"), EnvValue: ""},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		os.Clearenv()
+		_ = os.Setenv("OPTION_A", "from env")
+		_ = os.Setenv("OPTION_B", "")
+
+		report, err := godotenv.LoadWithReport(godotenv.LoadOptions{Overload: tt.overload}, "fixtures/plain.env")
+		if err != nil {
+			t.Fatalf("%s: error loading: %s", tt.name, err)
+		}
+
+		if !reflect.DeepEqual(tt.expected, report) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, report)
+		}
+	}
+}
+
+func TestReportRedact(t *testing.T) {
+	t.Parallel()
+
+	report := godotenv.Report{
+		Skipped: []godotenv.ReportEntry{{
+			Key:        "DATABASE_URL",
+			Definition: godotenv.Definition{Value: "postgres://secret", Filename: ".env", Line: 3, Raw: "DATABASE_URL=postgres://secret"},
+			EnvValue:   "postgres://other-secret",
+		}},
+	}
+
+	expected := godotenv.Report{
+		Skipped: []godotenv.ReportEntry{{
+			Key:        "DATABASE_URL",
+			Definition: godotenv.Definition{Value: "[REDACTED]", Filename: ".env", Line: 3, Raw: "DATABASE_URL=[REDACTED]"},
+			EnvValue:   "[REDACTED]",
+		}},
+	}
+
+	if redacted := report.Redact(); !reflect.DeepEqual(expected, redacted) {
+		t.Errorf("Expected %+v, got %+v", expected, redacted)
+	}
+
+	if report.Skipped[0].Value != "postgres://secret" {
+		t.Errorf("Expected the original report to be left alone")
+	}
+}
+
+func TestReportEntryString(t *testing.T) {
+	t.Parallel()
+
+	definition := godotenv.Definition{Value: "8080", Filename: ".env", Line: 3, Raw: "PORT=8080"}
+
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+	}{
+		{
+			name:     "applied",
+			value:    godotenv.ReportEntry{Key: "PORT", Definition: definition},
+			expected: "PORT=8080 (.env:3)",
+		},
+		{
+			name:     "skipped",
+			value:    godotenv.ReportEntry{Key: "PORT", Definition: definition, EnvValue: "9090"},
+			expected: "PORT=8080 (.env:3, environment: 9090)",
+		},
+		{
+			name:     "report",
+			value:    godotenv.Report{Applied: []godotenv.ReportEntry{{Key: "PORT", Definition: definition}}},
+			expected: "{[PORT=8080 (.env:3)] [] []}",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if actual := fmt.Sprintf("%v", tt.value); actual != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, actual)
+			}
+		})
+	}
+}
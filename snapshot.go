@@ -0,0 +1,71 @@
+package godotenv
+
+import (
+	"os"
+	"sort"
+)
+
+// Snapshot records the values variables had before they were set by Load, Overload or LoadWithOptions,
+// so that the environment can be put back with Restore. Set LoadOptions.Snapshot to record a snapshot:
+//
+//	var snapshot godotenv.Snapshot
+//	err := godotenv.LoadWithOptions(godotenv.LoadOptions{Snapshot: &snapshot}, ".env.test")
+//	defer snapshot.Restore()
+//
+// The same Snapshot can be used for multiple loads, in which case Restore puts back the values from
+// before the first load. The zero value is ready to use.
+type Snapshot struct {
+	prior map[string]priorValue
+}
+
+type priorValue struct {
+	value  string
+	exists bool
+}
+
+// record stores the current value of key, unless it has already been recorded.
+func (s *Snapshot) record(key string) {
+	if _, recorded := s.prior[key]; recorded {
+		return
+	}
+
+	if s.prior == nil {
+		s.prior = make(map[string]priorValue)
+	}
+
+	value, exists := os.LookupEnv(key)
+	s.prior[key] = priorValue{value: value, exists: exists}
+}
+
+// Keys returns the variables that will be restored, in sorted order.
+func (s *Snapshot) Keys() []string {
+	keys := make([]string, 0, len(s.prior))
+	for key := range s.prior {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// Restore puts the recorded variables back to their values from before they were loaded, and
+// unsets the variables that didn't exist. The snapshot is empty afterwards.
+func (s *Snapshot) Restore() error {
+	var firstErr error
+	for key, prior := range s.prior {
+		var err error
+		if prior.exists {
+			err = os.Setenv(key, prior.value)
+		} else {
+			err = os.Unsetenv(key)
+		}
+
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	s.prior = nil
+
+	return firstErr
+}
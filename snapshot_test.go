@@ -0,0 +1,58 @@
+package godotenv_test
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	os.Clearenv()
+	_ = os.Setenv("OPTION_A", "preset")
+	_ = os.Setenv("OPTION_B", "")
+	_ = os.Setenv("UNRELATED", "untouched")
+
+	var snapshot godotenv.Snapshot
+	err := godotenv.LoadWithOptions(godotenv.LoadOptions{Overload: true, Snapshot: &snapshot}, "fixtures/plain.env")
+	if err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+
+	// loading another file into the same snapshot keeps the values from before the first load
+	err = godotenv.LoadWithOptions(godotenv.LoadOptions{Overload: true, Snapshot: &snapshot}, "fixtures/base.env")
+	if err != nil {
+		t.Fatalf("Error loading: %s", err)
+	}
+
+	expectedKeys := []string{"DB_HOST", "DB_PORT", "OPTION_A", "OPTION_B", "OPTION_C"}
+	if keys := snapshot.Keys(); !reflect.DeepEqual(expectedKeys, keys) {
+		t.Errorf("Expected %v to be recorded, got %v", expectedKeys, keys)
+	}
+
+	if err = snapshot.Restore(); err != nil {
+		t.Fatalf("Error restoring: %s", err)
+	}
+
+	expected := map[string]string{
+		"OPTION_A":  "preset",
+		"OPTION_B":  "",
+		"UNRELATED": "untouched",
+	}
+	for k, v := range expected {
+		if envValue, exists := os.LookupEnv(k); !exists || envValue != v {
+			t.Errorf("Mismatch for key '%v': expected '%v' got '%v' (exists: %v)", k, v, envValue, exists)
+		}
+	}
+
+	for _, k := range []string{"OPTION_C", "DB_HOST", "DB_PORT"} {
+		if _, exists := os.LookupEnv(k); exists {
+			t.Errorf("Expected %s to be unset", k)
+		}
+	}
+
+	if keys := snapshot.Keys(); len(keys) != 0 {
+		t.Errorf("Expected the snapshot to be empty after restoring, got %v", keys)
+	}
+}
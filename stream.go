@@ -0,0 +1,380 @@
+package godotenv
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+)
+
+// A Decoder reads the entries of an env file from an input stream one at a time, rather than
+// reading the whole input into memory first.
+type Decoder struct {
+	r    *bufio.Reader
+	opts ParseOptions
+
+	// env holds the values read so far, so they can be referenced in later entries.
+	env map[string]string
+	// buf holds the lines of the statement that is being read, which starts on line and at
+	// offset in the input. scanner tracks whether the statement ends on the last line read.
+	buf     []byte
+	line    int
+	offset  int
+	scanner statementScanner
+	// pending holds the entries that were read along with a previous entry, such as those after a
+	// lone carriage return.
+	pending []Entry
+	err     error
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return NewDecoderWithOptions(r, ParseOptions{})
+}
+
+// NewDecoderWithOptions returns a new decoder that reads from r, and parses the input using opts.
+func NewDecoderWithOptions(r io.Reader, opts ParseOptions) *Decoder {
+	return &Decoder{
+		r:       bufio.NewReader(r),
+		opts:    opts,
+		env:     make(map[string]string),
+		line:    1,
+		scanner: statementScanner{commands: opts.CommandRunner != nil},
+	}
+}
+
+// Next returns the next entry in the input, skipping comments and blank lines. It returns io.EOF
+// when there are no more entries.
+//
+// Only the current statement, and the values of the entries read so far, are kept in memory. A
+// statement is read up to the line on which it ends, so a value with an unmatched quote is read
+// up to the end of the input.
+//
+// Parse errors are returned as they are found. Calling Next again continues with the next statement.
+func (d *Decoder) Next() (Entry, error) {
+	if len(d.pending) > 0 {
+		entry := d.pending[0]
+		d.pending = d.pending[1:]
+		return entry, nil
+	}
+
+	for d.err == nil {
+		line, err := d.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			d.err = err
+			break
+		}
+
+		atEOF := err == io.EOF
+		d.buf = append(d.buf, line...)
+
+		if !d.scanner.scan(line) && !atEOF {
+			continue
+		}
+
+		entries, err := d.parse()
+
+		d.line += bytes.Count(d.buf, []byte("\n"))
+		d.offset += len(d.buf)
+		d.buf = d.buf[:0]
+		d.scanner.reset()
+		if atEOF {
+			d.err = io.EOF
+		}
+
+		if err != nil {
+			return Entry{}, err
+		}
+		if len(entries) > 0 {
+			d.pending = entries[1:]
+			return entries[0], nil
+		}
+	}
+
+	return Entry{}, d.err
+}
+
+// statementScanner tracks where a statement ends while it's read one line at a time. It follows
+// the quotes, escapes, expansions and commands of the parser without expanding anything, so that
+// each line is only scanned once.
+type statementScanner struct {
+	commands bool
+
+	inValue bool
+	comment bool
+	escaped bool
+	prev    byte
+	// frames holds the quotes, expansions and commands that are open, innermost last.
+	frames []scanFrame
+}
+
+// scanFrame is a quote, ${...} expansion or $(...) command that is open.
+type scanFrame struct {
+	kind byte
+	// quoted and double report whether a ${...} expansion is in double quotes, and whether its
+	// word currently is. single reports whether its word is in single quotes.
+	quoted, double, single bool
+	// depth counts the nested braces or parentheses, and quote is the quote a command is in.
+	depth int
+	quote byte
+}
+
+// scan scans the line, reporting whether the statement ends on it.
+func (s *statementScanner) scan(line []byte) bool {
+	complete := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		complete = false
+		prev := s.prev
+		s.prev = c
+
+		if s.escaped {
+			s.escaped = false
+			continue
+		}
+
+		var next byte
+		if i+1 < len(line) {
+			next = line[i+1]
+		}
+
+		if len(s.frames) > 0 {
+			i += s.scanFrame(c, next)
+			continue
+		}
+
+		switch {
+		case s.comment:
+			if c == '\n' {
+				s.reset()
+				complete = true
+			}
+		case !s.inValue:
+			switch c {
+			case '=':
+				s.inValue = true
+			case '#':
+				s.comment = true
+			case '\n':
+				s.reset()
+				complete = true
+			}
+		case c == '\r' && next != '\n', c == '\n':
+			s.reset()
+			complete = true
+		case c == '\\':
+			s.escaped = true
+		case c == '\'', c == '"':
+			s.frames = append(s.frames, scanFrame{kind: c})
+		case c == '#' && unicode.IsSpace(rune(prev)):
+			s.comment = true
+		case c == '$':
+			i += s.push(next, false)
+		}
+	}
+
+	return complete
+}
+
+// scanFrame scans a byte inside the innermost frame, returning the number of extra bytes consumed.
+func (s *statementScanner) scanFrame(c, next byte) int {
+	f := &s.frames[len(s.frames)-1]
+
+	switch f.kind {
+	case '\'':
+		switch c {
+		case '\\':
+			s.escaped = true
+		case '\'':
+			s.pop()
+		}
+	case '"':
+		switch c {
+		case '\\':
+			s.escaped = true
+		case '"':
+			s.pop()
+		case '$':
+			return s.push(next, true)
+		}
+	case '{':
+		if f.single {
+			f.single = c != '\''
+			return 0
+		}
+
+		literal := f.double != f.quoted
+		switch {
+		case c == '\\':
+			s.escaped = true
+		case c == '\'':
+			f.single = !f.double
+		case c == '"':
+			f.double = !f.double
+		case c == '$':
+			return s.push(next, f.double)
+		case literal:
+		case c == '{':
+			f.depth++
+		case c == '}':
+			if f.depth == 0 {
+				s.pop()
+			} else {
+				f.depth--
+			}
+		}
+	case '(':
+		switch {
+		case c == '\\' && f.quote != '\'':
+			s.escaped = true
+		case f.quote != 0:
+			if c == f.quote {
+				f.quote = 0
+			}
+		case c == '\'', c == '"':
+			f.quote = c
+		case c == '(':
+			f.depth++
+		case c == ')':
+			if f.depth == 0 {
+				s.pop()
+			} else {
+				f.depth--
+			}
+		}
+	}
+
+	return 0
+}
+
+// push opens the expansion or command started by a '$' followed by next, returning the number of
+// extra bytes consumed.
+func (s *statementScanner) push(next byte, quoted bool) int {
+	switch {
+	case next == '{':
+		s.frames = append(s.frames, scanFrame{kind: '{', quoted: quoted, double: quoted})
+	case next == '(' && s.commands:
+		s.frames = append(s.frames, scanFrame{kind: '('})
+	default:
+		return 0
+	}
+
+	s.prev = next
+	return 1
+}
+
+func (s *statementScanner) pop() {
+	s.frames = s.frames[:len(s.frames)-1]
+}
+
+// reset prepares the scanner for the next statement.
+func (s *statementScanner) reset() {
+	s.inValue, s.comment, s.escaped = false, false, false
+	s.frames = s.frames[:0]
+}
+
+// parse parses the buffered statement, returning the entries it holds.
+func (d *Decoder) parse() (entries []Entry, err error) {
+	p := newParser(d.buf, d.opts)
+	p.firstLine, p.lineNumber = d.line, d.line
+	p.firstOffset = d.offset
+	p.doc = &Document{}
+	p.scope = d.env
+	p.define = func(key string, definition Definition) error {
+		d.env[key] = definition.Value
+		return nil
+	}
+
+	if err = p.parse(make(map[string]string), lookupEnvMap(d.env, d.opts.lookupEnv())); err != nil {
+		return nil, err
+	}
+
+	for _, n := range p.doc.Nodes {
+		if n.Kind == EntryNode {
+			entries = append(entries, *n.Entry)
+		}
+	}
+
+	return entries, nil
+}
+
+// An Encoder writes env files to an output stream, one entry at a time.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the variable to the stream, quoting the value the same way as Marshal does.
+func (e *Encoder) Encode(key, value string) error {
+	return e.EncodeEntry(Entry{Key: key, Value: value})
+}
+
+// EncodeEntry writes the entry to the stream, including the export prefix and inline comment.
+// The value is quoted the same way as Marshal does, regardless of the Quote of the entry.
+func (e *Encoder) EncodeEntry(entry Entry) error {
+	if !isValidKey(entry.Key) {
+		return fmt.Errorf("godotenv: invalid key %q", entry.Key)
+	}
+	if strings.ContainsAny(entry.Comment, "\r\n") {
+		return fmt.Errorf("godotenv: inline comment for %s cannot hold a line break", entry.Key)
+	}
+
+	var sb strings.Builder
+	if entry.Exported {
+		sb.WriteString(exportPrefix + " ")
+	}
+	sb.WriteString(entry.Key)
+	sb.WriteByte('=')
+	sb.WriteString(marshalValue(entry.Value))
+	if entry.Comment != "" {
+		sb.WriteString(" # " + entry.Comment)
+	}
+	sb.WriteByte('\n')
+
+	_, err := io.WriteString(e.w, sb.String())
+	return err
+}
+
+// EncodeComment writes a comment to the stream. Comments holding line breaks are written as
+// multiple comment lines.
+func (e *Encoder) EncodeComment(comment string) error {
+	var sb strings.Builder
+	comment = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(comment)
+	for _, line := range strings.Split(comment, "\n") {
+		sb.WriteString(strings.TrimRight("# "+line, " "))
+		sb.WriteByte('\n')
+	}
+
+	_, err := io.WriteString(e.w, sb.String())
+	return err
+}
+
+// EncodeBlank writes a blank line to the stream.
+func (e *Encoder) EncodeBlank() error {
+	_, err := io.WriteString(e.w, "\n")
+	return err
+}
+
+// isValidKey reports whether key can be read back by the parser.
+func isValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+
+	for i, c := range []byte(key) {
+		switch {
+		case c == '_', unicode.IsLetter(rune(c)):
+		case unicode.IsNumber(rune(c)) && i > 0:
+		default:
+			return false
+		}
+	}
+
+	return true
+}
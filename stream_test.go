@@ -0,0 +1,401 @@
+package godotenv_test
+
+import (
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestDecoderMatchesParse(t *testing.T) {
+	t.Parallel()
+
+	fixtures, err := filepath.Glob("fixtures/*.env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fixture := range fixtures {
+		fixture := fixture
+		if fixture == "fixtures/invalid1.env" {
+			continue
+		}
+
+		t.Run(fixture, func(t *testing.T) {
+			t.Parallel()
+
+			data, err := os.ReadFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			expected, err := godotenv.ParseWithLookup(strings.NewReader(string(data)), noLookupEnv)
+			if err != nil {
+				t.Fatalf("Error parsing: %s", err)
+			}
+
+			doc, err := godotenv.ParseDocument(strings.NewReader(string(data)))
+			if err != nil {
+				t.Fatalf("Error parsing document: %s", err)
+			}
+
+			dec := godotenv.NewDecoderWithOptions(strings.NewReader(string(data)), godotenv.ParseOptions{LookupEnv: noLookupEnv})
+			actual := make(map[string]string)
+			var lines []int
+			for {
+				entry, err := dec.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Error decoding: %s", err)
+				}
+
+				actual[entry.Key] = entry.Value
+				lines = append(lines, entry.Line)
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Mismatch env vars")
+				printDiff(t, expected, actual)
+			}
+
+			var expectedLines []int
+			for _, entry := range doc.Entries() {
+				expectedLines = append(expectedLines, entry.Line)
+			}
+			if !reflect.DeepEqual(expectedLines, lines) {
+				t.Errorf("Expected entries on lines %v, got %v", expectedLines, lines)
+			}
+		})
+	}
+}
+
+func TestDecoderContinuesAfterErrors(t *testing.T) {
+	t.Parallel()
+
+	input := "A=1\nB C=2\n# comment\nD=\"multi\nline $A\"\nE=${UNSET?}\nF=last"
+	dec := godotenv.NewDecoderWithOptions(strings.NewReader(input), godotenv.ParseOptions{LookupEnv: noLookupEnv})
+
+	type result struct {
+		key, value string
+		line       int
+		kind       godotenv.ErrorKind
+	}
+	expected := []result{
+		{key: "A", value: "1", line: 1},
+		{line: 2, kind: godotenv.KindInvalidKey},
+		{key: "D", value: "multi\nline 1", line: 4},
+		{line: 6, kind: godotenv.KindRequiredVariable},
+		{key: "F", value: "last", line: 7},
+	}
+
+	var actual []result
+	for {
+		entry, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr godotenv.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a ParseError, got %v", err)
+			}
+			actual = append(actual, result{line: parseErr.Line, kind: parseErr.Kind})
+			continue
+		}
+
+		actual = append(actual, result{key: entry.Key, value: entry.Value, line: entry.Line})
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected %+v, got %+v", expected, actual)
+	}
+
+	if _, err := dec.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF after the end of the input, got %v", err)
+	}
+}
+
+func TestDecoderRunsCommandsOnce(t *testing.T) {
+	t.Parallel()
+
+	calls := 0
+	runner := godotenv.CommandRunnerFunc(func(command string, env map[string]string) ([]byte, error) {
+		calls++
+		return []byte("out"), nil
+	})
+
+	dec := godotenv.NewDecoderWithOptions(strings.NewReader("A=\"$(cmd)\n\n\nmore\"\n"), godotenv.ParseOptions{CommandRunner: runner})
+	entry, err := dec.Next()
+	if err != nil {
+		t.Fatalf("Error decoding: %s", err)
+	}
+
+	if entry.Value != "out\n\n\nmore" || calls != 1 {
+		t.Errorf("Expected the command to run once, got %q after %d calls", entry.Value, calls)
+	}
+}
+
+func TestDecoderCommandsSeeEarlierEntries(t *testing.T) {
+	t.Parallel()
+
+	runner := godotenv.CommandRunnerFunc(func(command string, env map[string]string) ([]byte, error) {
+		return []byte(env["A"]), nil
+	})
+
+	dec := godotenv.NewDecoderWithOptions(strings.NewReader("A=1\nB=$(cmd)\n"), godotenv.ParseOptions{CommandRunner: runner})
+	for _, expected := range []string{"1", "1"} {
+		entry, err := dec.Next()
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if entry.Value != expected {
+			t.Errorf("Expected %s to be %q, got %q", entry.Key, expected, entry.Value)
+		}
+	}
+}
+
+func TestDecoderStatementEnds(t *testing.T) {
+	t.Parallel()
+
+	tests := []string{
+		"A='multi\nline'\nB=2\n",
+		"A='it\\'s\nstill quoted'\nB=2\n",
+		"A=\"escaped \\\" quote\nline\"\nB=2\n",
+		"A=escaped\\\nnewline\nB=2\n",
+		"A=1 # it's a comment\nB=2\n",
+		"A=a#'b\nc'\nB=2\n",
+		"A=${UNSET:-\"multi\nline\"}\nB=2\n",
+		"A=${UNSET:-'}'}\nB=2\n",
+		"A=\"${UNSET:-'}\"\nB=2\n",
+		"A=${UNSET:-${UNSET:-{x}\ny}}\nB=2\n",
+		"A=$(not a command\nB=2\n",
+		"A=1\rB='2\n3'\nC=4\n",
+		"# it's a comment\nA=1\n",
+	}
+
+	for _, input := range tests {
+		input := input
+		t.Run(input, func(t *testing.T) {
+			t.Parallel()
+
+			expected, err := godotenv.ParseWithLookup(strings.NewReader(input), noLookupEnv)
+			if err != nil {
+				t.Fatalf("Error parsing: %s", err)
+			}
+
+			dec := godotenv.NewDecoderWithOptions(strings.NewReader(input), godotenv.ParseOptions{LookupEnv: noLookupEnv})
+			actual := make(map[string]string)
+			for {
+				entry, err := dec.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Error decoding: %s", err)
+				}
+
+				actual[entry.Key] = entry.Value
+			}
+
+			if !reflect.DeepEqual(expected, actual) {
+				t.Errorf("Mismatch env vars")
+				printDiff(t, expected, actual)
+			}
+		})
+	}
+}
+
+// TestDecoderMatchesParseOnGeneratedInput checks that the Decoder, which finds the end of each
+// statement without parsing it, splits the input the same way as the parser would.
+func TestDecoderMatchesParseOnGeneratedInput(t *testing.T) {
+	t.Parallel()
+
+	keys := []string{"A=", "B=", "export A=", "# comment ", ""}
+	tokens := []string{
+		"'", "\"", "\\", "\n", "\r", " ", "#", "$", "{", "}", "(", ")", ":-", "/#", "%", "${A", "$(", "x",
+	}
+	runner := godotenv.CommandRunnerFunc(func(command string, env map[string]string) ([]byte, error) {
+		return []byte("out"), nil
+	})
+	opts := godotenv.ParseOptions{LookupEnv: noLookupEnv, CommandRunner: runner}
+
+	rnd := rand.New(rand.NewSource(1))
+	for n := 0; n < 50000; n++ {
+		var sb strings.Builder
+		for i := rnd.Intn(4); i >= 0; i-- {
+			sb.WriteString(keys[rnd.Intn(len(keys))])
+			for j := rnd.Intn(12); j > 0; j-- {
+				sb.WriteString(tokens[rnd.Intn(len(tokens))])
+			}
+			sb.WriteString("\n")
+		}
+		input := sb.String()
+
+		expected, expectedErr := godotenv.ParseWithOptions(strings.NewReader(input), opts)
+
+		dec := godotenv.NewDecoderWithOptions(strings.NewReader(input), opts)
+		actual := make(map[string]string)
+		var actualErr error
+		for actualErr == nil {
+			entry, err := dec.Next()
+			if err == io.EOF {
+				break
+			}
+			actualErr = err
+			if err == nil {
+				actual[entry.Key] = entry.Value
+			}
+		}
+
+		if expectedErr != nil || actualErr != nil {
+			if expectedErr == nil || actualErr == nil || expectedErr.Error() != actualErr.Error() {
+				t.Fatalf("Expected %q to fail with %v, got %v", input, expectedErr, actualErr)
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(expected, actual) {
+			t.Fatalf("Expected %q to decode as %q, got %q", input, expected, actual)
+		}
+	}
+}
+
+func TestDecoderLongValue(t *testing.T) {
+	t.Parallel()
+
+	value := strings.Repeat("a line of a long value\n", 20000)
+	dec := godotenv.NewDecoder(strings.NewReader("A=\"" + value + "\"\nB=$A\n"))
+
+	for _, key := range []string{"A", "B"} {
+		entry, err := dec.Next()
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		if entry.Key != key || entry.Value != value {
+			t.Errorf("Expected %s to hold the long value, got %s with %d bytes", key, entry.Key, len(entry.Value))
+		}
+	}
+}
+
+func TestDecoderErrorOffsets(t *testing.T) {
+	t.Parallel()
+
+	input := "A=1\nB C=2\nD=\"multi\nline\"\nE=${UNSET?}\nF=\"unterminated"
+
+	type position struct{ line, column, offset int }
+	positions := func(errs []error) []position {
+		var p []position
+		for _, err := range errs {
+			var parseErr godotenv.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Expected a ParseError, got %v", err)
+			}
+			p = append(p, position{parseErr.Line, parseErr.Column, parseErr.Offset})
+		}
+		return p
+	}
+
+	_, err := godotenv.ParseWithOptions(strings.NewReader(input), godotenv.ParseOptions{LookupEnv: noLookupEnv, AllErrors: true})
+	var parseErrs godotenv.ParseErrors
+	if !errors.As(err, &parseErrs) {
+		t.Fatalf("Expected ParseErrors, got %v", err)
+	}
+
+	var decodeErrs []error
+	dec := godotenv.NewDecoderWithOptions(strings.NewReader(input), godotenv.ParseOptions{LookupEnv: noLookupEnv})
+	for {
+		_, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			decodeErrs = append(decodeErrs, err)
+		}
+	}
+
+	expected := []position{{2, 2, 5}, {5, 3, 27}, {6, 16, 52}}
+	if actual := positions(parseErrs); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected Parse to report errors at %v, got %v", expected, actual)
+	}
+	if actual := positions(decodeErrs); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected Decoder to report errors at %v, got %v", expected, actual)
+	}
+}
+
+func TestEncoder(t *testing.T) {
+	t.Parallel()
+
+	var sb strings.Builder
+	enc := godotenv.NewEncoder(&sb)
+
+	steps := []func() error{
+		func() error { return enc.EncodeComment("Database settings\nfor local development") },
+		func() error { return enc.Encode("DB_HOST", "localhost") },
+		func() error { return enc.Encode("DB_PASSWORD", "it's $ecret") },
+		func() error { return enc.EncodeBlank() },
+		func() error {
+			return enc.EncodeEntry(godotenv.Entry{Key: "PORT", Value: "8080", Exported: true, Comment: "the port"})
+		},
+		func() error { return enc.EncodeEntry(godotenv.Entry{Key: "EMPTY", Comment: "left empty"}) },
+	}
+	for _, step := range steps {
+		if err := step(); err != nil {
+			t.Fatalf("Error encoding: %s", err)
+		}
+	}
+
+	expected := `# Database settings
+# for local development
+DB_HOST='localhost'
+DB_PASSWORD="it's \$ecret"
+
+export PORT='8080' # the port
+EMPTY='' # left empty
+`
+	if sb.String() != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+
+	envMap, err := godotenv.Unmarshal(sb.String())
+	if err != nil {
+		t.Fatalf("Error unmarshalling: %s", err)
+	}
+
+	expectedEnv := map[string]string{"DB_HOST": "localhost", "DB_PASSWORD": "it's $ecret", "PORT": "8080", "EMPTY": ""}
+	if !reflect.DeepEqual(expectedEnv, envMap) {
+		t.Errorf("Mismatch env vars")
+		printDiff(t, expectedEnv, envMap)
+	}
+
+	dec := godotenv.NewDecoder(strings.NewReader(sb.String()))
+	decoded := make(map[string]string)
+	for {
+		entry, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Error decoding: %s", err)
+		}
+		decoded[entry.Key] = entry.Value
+	}
+	if !reflect.DeepEqual(expectedEnv, decoded) {
+		t.Errorf("Mismatch decoded env vars")
+		printDiff(t, expectedEnv, decoded)
+	}
+
+	for _, key := range []string{"", "1A", "A-B", "A B"} {
+		if err := enc.Encode(key, "x"); err == nil {
+			t.Errorf("Expected an error for key %q", key)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package godotenv
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// DefaultFileMode is the mode WriteFile creates new files with, as env files often hold secrets.
+const DefaultFileMode fs.FileMode = 0o600
+
+// WriteOptions configures how WriteFile writes env files.
+type WriteOptions struct {
+	// Mode is the mode new files are created with. It defaults to DefaultFileMode. Existing files
+	// keep their mode.
+	Mode fs.FileMode
+
+	// Backup keeps a copy of the existing file, with .bak appended to the name.
+	Backup bool
+}
+
+// WriteFile serializes the given environment with Marshal and writes it to a file atomically: the
+// content is written to a temporary file in the same directory first, which replaces the file once
+// it's completely written and synced. This means that the file is never left partially written.
+//
+// Existing files keep their mode and, where possible, their owner. If the file is a symlink, the file it
+// points to is replaced.
+func WriteFile(envMap map[string]string, filename string, opts WriteOptions) error {
+	content, err := Marshal(envMap)
+	if err != nil {
+		return err
+	}
+
+	if target, err := filepath.EvalSymlinks(filename); err == nil {
+		filename = target
+	}
+
+	mode := opts.Mode
+	if mode == 0 {
+		mode = DefaultFileMode
+	}
+
+	existing, err := os.Stat(filename)
+	switch {
+	case err == nil:
+		mode = existing.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if existing != nil && opts.Backup {
+		if err = copyFile(filename, filename+".bak", mode); err != nil {
+			return err
+		}
+	}
+
+	dir := filepath.Dir(filename)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".tmp-*")
+	if err != nil {
+		return err
+	}
+
+	// remove the temporary file if anything goes wrong. This fails once it has been renamed.
+	defer os.Remove(tmp.Name())
+
+	if err = writeTemp(tmp, content+"\n", mode, existing); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp.Name(), filename); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// writeTemp writes the content to the temporary file and syncs it, using the mode and owner of
+// existing if it's set.
+func writeTemp(tmp *os.File, content string, mode fs.FileMode, existing fs.FileInfo) error {
+	defer tmp.Close()
+
+	if err := tmp.Chmod(mode); err != nil {
+		return err
+	}
+
+	if existing != nil {
+		if err := chown(tmp, existing); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tmp.WriteString(content); err != nil {
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+
+	return tmp.Close()
+}
+
+// copyFile copies src to dst, creating or truncating dst with the given mode.
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	// the mode is only applied when the file is created
+	if err = out.Chmod(mode); err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+
+	if err = out.Sync(); err != nil {
+		return err
+	}
+
+	return out.Close()
+}
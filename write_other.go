@@ -0,0 +1,38 @@
+//go:build !windows
+// +build !windows
+
+package godotenv
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// chown gives file the same owner as existing. Not being allowed to do so is ignored, as only
+// privileged users can give away files, in which case the file is owned by the current user.
+func chown(file *os.File, existing fs.FileInfo) error {
+	stat, ok := existing.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+
+	err := file.Chown(int(stat.Uid), int(stat.Gid))
+	if errors.Is(err, fs.ErrPermission) {
+		return nil
+	}
+
+	return err
+}
+
+// syncDir syncs the directory, so that a rename within it is persisted.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
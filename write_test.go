@@ -0,0 +1,112 @@
+package godotenv_test
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/hoshsadiq/godotenv"
+)
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	filename := filepath.Join(dir, ".env")
+
+	if err := godotenv.Write(map[string]string{"A": "1"}, filename); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+	assertFile(t, filename, "A='1'\n", godotenv.DefaultFileMode)
+
+	if runtime.GOOS != "windows" {
+		if err := os.Chmod(filename, 0o640); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := godotenv.WriteFile(map[string]string{"A": "2"}, filename, godotenv.WriteOptions{Backup: true})
+	if err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+	assertFile(t, filename, "A='2'\n", 0o640)
+	assertFile(t, filename+".bak", "A='1'\n", 0o640)
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if expected := []string{".env", ".env.bak"}; !reflect.DeepEqual(expected, names) {
+		t.Errorf("Expected only %v to be left, got %v", expected, names)
+	}
+}
+
+func TestWriteFileMode(t *testing.T) {
+	t.Parallel()
+
+	filename := filepath.Join(t.TempDir(), ".env")
+
+	err := godotenv.WriteFile(map[string]string{"A": "1"}, filename, godotenv.WriteOptions{Mode: 0o644})
+	if err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+	assertFile(t, filename, "A='1'\n", 0o644)
+}
+
+func TestWriteFileSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symlinks require elevated privileges on windows")
+	}
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "shared.env")
+	link := filepath.Join(dir, ".env")
+
+	if err := godotenv.Write(map[string]string{"A": "1"}, target); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+	if err := os.Symlink("shared.env", link); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := godotenv.Write(map[string]string{"A": "2"}, link); err != nil {
+		t.Fatalf("Error writing: %s", err)
+	}
+
+	if info, err := os.Lstat(link); err != nil || info.Mode()&fs.ModeSymlink == 0 {
+		t.Errorf("Expected %s to still be a symlink", link)
+	}
+	assertFile(t, target, "A='2'\n", godotenv.DefaultFileMode)
+}
+
+func assertFile(t *testing.T, filename, content string, mode fs.FileMode) {
+	t.Helper()
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Errorf("Expected %s to hold %q, got %q", filename, content, data)
+	}
+
+	if runtime.GOOS == "windows" {
+		return
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != mode {
+		t.Errorf("Expected %s to have mode %v, got %v", filename, mode, info.Mode().Perm())
+	}
+}
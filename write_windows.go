@@ -0,0 +1,16 @@
+package godotenv
+
+import (
+	"io/fs"
+	"os"
+)
+
+// chown is a no-op on Windows, where files don't have a uid and gid.
+func chown(*os.File, fs.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op on Windows, where directories cannot be synced.
+func syncDir(string) error {
+	return nil
+}